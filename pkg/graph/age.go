@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tmc/dot"
+)
+
+// The fills of the newest and oldest resources, lightgreen and lightgray.
+var (
+	newFill = [3]float64{144, 238, 144}
+	oldFill = [3]float64{211, 211, 211}
+)
+
+// fillByAge fills the node of each resource along a gradient from green for
+// the newest resource in the graph to gray for the oldest. Nodes whose fill
+// was changed since the last render, for example by Focus, are left alone.
+func (g *Graph) fillByAge() {
+	var newest, oldest time.Time
+	for _, info := range g.info {
+		if info.created.IsZero() {
+			continue
+		}
+		if newest.IsZero() || info.created.After(newest) {
+			newest = info.created
+		}
+		if oldest.IsZero() || info.created.Before(oldest) {
+			oldest = info.created
+		}
+	}
+	span := newest.Sub(oldest)
+
+	for k, n := range g.nodes {
+		created := g.info[k].created
+		if created.IsZero() {
+			continue
+		}
+		if last, ok := g.ageFills[n]; ok && g.baseFill(n) != last {
+			continue
+		}
+		t := 0.0
+		if span > 0 {
+			t = float64(newest.Sub(created)) / float64(span)
+		}
+		var rgb [3]uint8
+		for i := range rgb {
+			rgb[i] = uint8(newFill[i] + t*(oldFill[i]-newFill[i]))
+		}
+		fill := fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2])
+		_ = n.Set("fillcolor", fill)
+		g.ageFills[n] = fill
+	}
+}
+
+// baseFill returns the fill color of n before opacity was applied to it.
+func (g *Graph) baseFill(n *dot.Node) string {
+	current := n.Get("fillcolor")
+	if f, ok := g.fills[n]; ok && current == f.applied {
+		return f.base
+	}
+	return current
+}
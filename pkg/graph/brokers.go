@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"sort"
+
+	"github.com/tmc/dot"
+)
+
+// BrokersWithoutTriggers returns the keys of the brokers whose cluster holds
+// no triggers, sorted.
+func (g *Graph) BrokersWithoutTriggers() []string {
+	used := make(map[*dot.SubGraph]bool)
+	for k, n := range g.nodes {
+		if g.info[k].kind != "Trigger" {
+			continue
+		}
+		for sg := g.parent[n]; sg != nil; sg = g.clusterParent[sg] {
+			used[sg] = true
+		}
+	}
+
+	var idle []string
+	for k := range g.nodes {
+		if g.info[k].kind != "Broker" {
+			continue
+		}
+		sg, ok := g.subgraphs[k]
+		if !ok || used[sg] || len(g.triggerRows[triggerTableKey(k)]) > 0 {
+			continue
+		}
+		idle = append(idle, k)
+	}
+	sort.Strings(idle)
+	return idle
+}
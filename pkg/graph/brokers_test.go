@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func TestBrokersWithoutTriggers(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "trigger nodes"},
+		{name: "grouped by subscriber", opts: []Option{WithGroupTriggersBySubscriber(true)}},
+		{name: "trigger table", opts: []Option{WithTriggerTable(true)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", tt.opts...)
+			g.AddBroker(newBroker("default", "busy"))
+			g.AddBroker(newBroker("default", "idle"))
+			g.AddTrigger(newTrigger("default", "t", "busy", *serviceRef("svc")))
+
+			want := []string{"eventing.knative.dev/broker/idle"}
+			if got := g.BrokersWithoutTriggers(); !reflect.DeepEqual(got, want) {
+				t.Errorf("BrokersWithoutTriggers() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func brokerRef(name string) *duckv1.Destination {
+	return &duckv1.Destination{Ref: &duckv1.KReference{
+		Kind:       "Broker",
+		APIVersion: "eventing.knative.dev/v1beta1",
+		Name:       name,
+	}}
+}
+
+func TestBrokerDeadLetterChain(t *testing.T) {
+	first := newBroker("default", "first")
+	first.Spec.Delivery = &eventingduckv1beta1.DeliverySpec{DeadLetterSink: brokerRef("second")}
+	second := newBroker("default", "second")
+	second.Spec.Delivery = &eventingduckv1beta1.DeliverySpec{DeadLetterSink: brokerRef("last")}
+	last := newBroker("default", "last")
+
+	tests := []struct {
+		name    string
+		brokers []eventingv1beta1.Broker
+	}{
+		{name: "chain order", brokers: []eventingv1beta1.Broker{first, second, last}},
+		{name: "reverse order", brokers: []eventingv1beta1.Broker{last, second, first}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			for _, b := range tt.brokers {
+				g.AddBroker(b)
+			}
+
+			got := edgesOf(g, relDeadLetter)
+			sort.Slice(got, func(i, j int) bool { return got[i].From < got[j].From })
+			want := []Edge{
+				{From: "eventing.knative.dev/broker/first", To: "eventing.knative.dev/broker/second", Relationship: relDeadLetter},
+				{From: "eventing.knative.dev/broker/second", To: "eventing.knative.dev/broker/last", Relationship: relDeadLetter},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("dead letter edges = %v, want %v", got, want)
+			}
+			for _, e := range want {
+				de := findEdge(t, g, e.From, e.To)
+				if got, want := de.Get("ltail"), g.subgraphs[e.From].Name(); got != want {
+					t.Errorf("%s leaves at %q, want its ingress cluster %q", e.From, got, want)
+				}
+				if got, want := de.Get("lhead"), g.subgraphs[e.To].Name(); got != want {
+					t.Errorf("%s is entered at %q, want its ingress cluster %q", e.To, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package graph
+
+import (
+	"github.com/tmc/dot"
+)
+
+// Components splits the graph into its weakly connected components, in the
+// order their first node was added.
+func (g *Graph) Components() []*Graph {
+	tracked := make(map[*dot.Node]bool, len(g.nodes))
+	for _, n := range g.nodes {
+		tracked[n] = true
+	}
+	neighbors := make(map[*dot.Node][]*dot.Node)
+	for _, e := range g.edges {
+		src, dst := e.Source(), e.Destination()
+		tracked[src], tracked[dst] = true, true
+		neighbors[src] = append(neighbors[src], dst)
+		neighbors[dst] = append(neighbors[dst], src)
+	}
+
+	seen := make(map[*dot.Node]bool)
+	var components []*Graph
+	for _, n := range g.order {
+		if !tracked[n] || seen[n] {
+			continue
+		}
+		keep := map[*dot.Node]bool{n: true}
+		seen[n] = true
+		queue := []*dot.Node{n}
+		for len(queue) > 0 {
+			next := queue[0]
+			queue = queue[1:]
+			for _, m := range neighbors[next] {
+				if !seen[m] {
+					seen[m] = true
+					keep[m] = true
+					queue = append(queue, m)
+				}
+			}
+		}
+		components = append(components, g.subset(keep))
+	}
+	return components
+}
+
+// subset returns a copy of the graph with only the nodes in keep, the edges
+// between them and the clusters holding them.
+func (g *Graph) subset(keep map[*dot.Node]bool) *Graph {
+	f := g.fork()
+	f.Graph = copyGraph(g.Graph)
+	f.order = nil
+	f.keys = make(map[*dot.Node]string, len(keep))
+	f.clusters = nil
+	f.parent = make(map[*dot.Node]*dot.SubGraph)
+	f.clusterParent = make(map[*dot.SubGraph]*dot.SubGraph)
+	f.edgeIDs = make(map[string]int)
+	f.notReady = make(map[*dot.Node]bool)
+	f.opacity = make(map[*dot.Node]float64)
+	f.fills = make(map[*dot.Node]fill)
+	f.ageFills = make(map[*dot.Node]string)
+	f.degreeSuffix = make(map[*dot.Node]string)
+	f.warnings = nil
+	f.legend = nil
+
+	used := make(map[*dot.SubGraph]bool)
+	nodes := make(map[*dot.Node]*dot.Node, len(keep))
+	for _, n := range g.order {
+		if !keep[n] {
+			continue
+		}
+		nodes[n] = copyNode(n, n.Name())
+		for sg := g.parent[n]; sg != nil; sg = g.clusterParent[sg] {
+			used[sg] = true
+		}
+	}
+
+	clusters := make(map[*dot.SubGraph]*dot.SubGraph, len(used))
+	for _, sg := range g.clusters {
+		if used[sg] {
+			clusters[sg] = copyCluster(sg, sg.Name())
+		}
+	}
+	for _, sg := range g.clusters {
+		if used[sg] {
+			f.addCluster(clusters[g.clusterParent[sg]], clusters[sg])
+		}
+	}
+	for _, n := range g.order {
+		if c, ok := nodes[n]; ok {
+			f.addNodeTo(clusters[g.parent[n]], c)
+			if g.notReady[n] {
+				f.notReady[c] = true
+			}
+			if suffix, ok := g.degreeSuffix[n]; ok {
+				f.degreeSuffix[c] = suffix
+			}
+			if opacity, ok := g.opacity[n]; ok {
+				f.opacity[c] = opacity
+			}
+			if fl, ok := g.fills[n]; ok {
+				f.fills[c] = fl
+			}
+			if fill, ok := g.ageFills[n]; ok {
+				f.ageFills[c] = fill
+			}
+		}
+	}
+
+	for k, n := range g.nodes {
+		if c, ok := nodes[n]; ok {
+			f.track(k, c)
+		} else {
+			delete(f.nodes, k)
+			delete(f.info, k)
+		}
+	}
+	for dns, k := range f.dnsToKey {
+		if _, ok := f.nodes[k]; !ok {
+			delete(f.dnsToKey, dns)
+		}
+	}
+	for target, froms := range g.pendingDeadLetters {
+		var pending []*dot.Node
+		for _, from := range froms {
+			if c, ok := nodes[from]; ok {
+				pending = append(pending, c)
+			}
+		}
+		f.pendingDeadLetters[target] = pending
+	}
+	for _, m := range []struct{ from, to map[string]*dot.SubGraph }{
+		{g.subgraphs, f.subgraphs},
+		{g.sourceGroups, f.sourceGroups},
+		{g.triggerGroups, f.triggerGroups},
+		{g.partOfGroups, f.partOfGroups},
+		{g.namespaceGroups, f.namespaceGroups},
+	} {
+		for k, sg := range m.from {
+			if c, ok := clusters[sg]; ok {
+				m.to[k] = c
+			} else {
+				delete(m.to, k)
+			}
+		}
+	}
+
+	f.edges = nil
+	for _, e := range g.edges {
+		src, srcOK := nodes[e.Source()]
+		dst, dstOK := nodes[e.Destination()]
+		if !srcOK || !dstOK {
+			continue
+		}
+		c := copyEdge(e.Edge, src, dst)
+		f.edges = append(f.edges, &edge{Edge: c, rel: e.rel})
+		f.edgeIDs[c.Get("id")]++
+		f.Graph.AddEdge(c)
+	}
+	return f
+}
@@ -0,0 +1,46 @@
+package graph
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestComponents(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "first"))
+	g.AddTrigger(newTrigger("default", "t1", "first", *serviceRef("svc1")))
+	g.AddBroker(newBroker("default", "second"))
+	g.AddTrigger(newTrigger("default", "t2", "second", *serviceRef("svc2")))
+
+	components := g.Components()
+	if len(components) != 2 {
+		t.Fatalf("got %d components, want 2", len(components))
+	}
+	want := [][]string{{
+		"eventing.knative.dev/broker/first",
+		"eventing.knative.dev/trigger/t1",
+		"serving.knative.dev/service/svc1",
+	}, {
+		"eventing.knative.dev/broker/second",
+		"eventing.knative.dev/trigger/t2",
+		"serving.knative.dev/service/svc2",
+	}}
+	for i, c := range components {
+		var keys []string
+		for k := range c.nodes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		if !reflect.DeepEqual(keys, want[i]) {
+			t.Errorf("component %d has nodes %q, want %q", i, keys, want[i])
+		}
+		if got := len(c.edges); got != 2 {
+			t.Errorf("component %d has %d edges, want 2", i, got)
+		}
+		if out := c.String(); !strings.Contains(out, "digraph") {
+			t.Errorf("component %d does not render:\n%s", i, out)
+		}
+	}
+}
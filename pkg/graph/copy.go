@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"github.com/tmc/dot"
+)
+
+// The attributes Graphviz understands per object type. dot does not expose
+// the attributes set on an object, so copies check each of these.
+var (
+	graphAttributes = []string{"Damping", "K", "URL", "aspect", "bb", "bgcolor",
+		"center", "charset", "clusterrank", "colorscheme", "comment", "compound",
+		"concentrate", "defaultdist", "dim", "dimen", "diredgeconstraints",
+		"dpi", "epsilon", "esep", "fontcolor", "fontname", "fontnames",
+		"fontpath", "fontsize", "id", "label", "labeljust", "labelloc",
+		"landscape", "layers", "layersep", "layout", "levels", "levelsgap",
+		"lheight", "lp", "lwidth", "margin", "maxiter", "mclimit", "mindist",
+		"mode", "model", "mosek", "nodesep", "nojustify", "normalize", "nslimit",
+		"nslimit1", "ordering", "orientation", "outputorder", "overlap",
+		"overlap_scaling", "pack", "packmode", "pad", "page", "pagedir",
+		"quadtree", "quantum", "rankdir", "ranksep", "ratio", "remincross",
+		"repulsiveforce", "resolution", "root", "rotate", "searchsize", "sep",
+		"showboxes", "size", "smoothing", "sortv", "splines", "start",
+		"stylesheet", "target", "tooltip", "truecolor", "viewport", "voro_margin"}
+
+	nodeAttributes = []string{"URL", "color", "colorscheme", "comment",
+		"distortion", "fillcolor", "fixedsize", "fontcolor", "fontname",
+		"fontsize", "group", "height", "id", "image", "imagescale", "label",
+		"labelloc", "layer", "margin", "nojustify", "orientation", "penwidth",
+		"peripheries", "pin", "pos", "rects", "regular", "root", "samplepoints",
+		"shape", "shapefile", "showboxes", "sides", "skew", "sortv", "style",
+		"target", "tooltip", "vertices", "width", "z"}
+
+	edgeAttributes = []string{"URL", "arrowhead", "arrowsize", "arrowtail",
+		"color", "colorscheme", "comment", "constraint", "decorate", "dir",
+		"edgeURL", "edgehref", "edgetarget", "edgetooltip", "fontcolor",
+		"fontname", "fontsize", "headURL", "headclip", "headhref", "headlabel",
+		"headport", "headtarget", "headtooltip", "href", "id", "label",
+		"labelURL", "labelangle", "labeldistance", "labelfloat", "labelfontcolor",
+		"labelfontname", "labelfontsize", "labelhref", "labeltarget",
+		"labeltooltip", "layer", "len", "lhead", "lp", "ltail", "minlen",
+		"nojustify", "penwidth", "pos", "samehead", "sametail", "showboxes",
+		"style", "tailURL", "tailclip", "tailhref", "taillabel", "tailport",
+		"tailtarget", "tailtooltip", "target", "tooltip", "weight"}
+
+	// dot only accepts graph attributes on subgraphs, so these are the
+	// graph attributes that apply to clusters.
+	clusterAttributes = []string{"URL", "bgcolor", "colorscheme", "fontcolor",
+		"fontname", "fontsize", "id", "label", "labeljust", "labelloc",
+		"lheight", "lp", "lwidth", "margin", "nojustify", "rank", "rankdir",
+		"sortv", "target"}
+)
+
+// copyGraph returns an empty graph with the name and attributes of g.
+func copyGraph(g *dot.Graph) *dot.Graph {
+	c := dot.NewGraph(g.Name())
+	for _, attr := range graphAttributes {
+		if v := g.Get(attr); v != "" {
+			_ = c.Set(attr, v)
+		}
+	}
+	return c
+}
+
+// copyNode returns a copy of n named name. Nodes without a label keep
+// showing their original name.
+func copyNode(n *dot.Node, name string) *dot.Node {
+	c := dot.NewNode(name)
+	for _, attr := range nodeAttributes {
+		if v := n.Get(attr); v != "" {
+			_ = c.Set(attr, v)
+		}
+	}
+	if c.Get("label") == "" {
+		_ = c.Set("label", n.Name())
+	}
+	return c
+}
+
+// copyEdge returns a copy of e between src and dst.
+func copyEdge(e *dot.Edge, src, dst *dot.Node) *dot.Edge {
+	c := dot.NewEdge(src, dst)
+	for _, attr := range edgeAttributes {
+		if v := e.Get(attr); v != "" {
+			_ = c.Set(attr, v)
+		}
+	}
+	return c
+}
+
+// copyCluster returns a copy of the attributes of sg, without its contents,
+// named name.
+func copyCluster(sg *dot.SubGraph, name string) *dot.SubGraph {
+	c := dot.NewSubgraph(name)
+	for _, attr := range clusterAttributes {
+		if v := sg.Get(attr); v != "" {
+			_ = c.Set(attr, v)
+		}
+	}
+	return c
+}
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"sort"
+
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+)
+
+// FilterCoverage compares the type filters of the triggers on the broker
+// with brokerKey against the event types known to reach it, from EventTypes
+// and the CloudEvent attributes of sources. It returns the known types some
+// trigger accepts and all the known types, both sorted.
+func (g *Graph) FilterCoverage(brokerKey string) (covered []string, all []string) {
+	accepted := make(map[string]bool)
+	wildcard := false
+	for _, t := range g.brokerFilters[brokerKey] {
+		if t == "" {
+			wildcard = true
+		}
+		accepted[t] = true
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range g.brokerTypes[brokerKey] {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		all = append(all, t)
+		if wildcard || accepted[t] {
+			covered = append(covered, t)
+		}
+	}
+	sort.Strings(covered)
+	sort.Strings(all)
+	return covered, all
+}
+
+// recordFilter remembers the type the trigger filters on, or "" if it
+// accepts any type, and its whole filter.
+func (g *Graph) recordFilter(bk string, trigger eventingv1beta1.Trigger) {
+	t := ""
+	var attributes map[string]string
+	if trigger.Spec.Filter != nil {
+		t = trigger.Spec.Filter.Attributes["type"]
+		attributes = trigger.Spec.Filter.Attributes
+	}
+	g.brokerFilters[bk] = append(g.brokerFilters[bk], t)
+	g.triggerFilters[bk] = append(g.triggerFilters[bk], triggerFilter{
+		key:        g.triggerKey(trigger.Namespace, trigger.Name),
+		attributes: attributes,
+	})
+}
+
+// recordEventTypes remembers the event types known to reach the broker with
+// key bk.
+func (g *Graph) recordEventTypes(bk string, types ...string) {
+	if bk == "" {
+		return
+	}
+	for _, t := range types {
+		if t != "" {
+			g.brokerTypes[bk] = append(g.brokerTypes[bk], t)
+		}
+	}
+}
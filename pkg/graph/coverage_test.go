@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func TestFilterCoverage(t *testing.T) {
+	filtered := func(name, eventType string) eventingv1beta1.Trigger {
+		trigger := newTrigger("default", name, "default", *serviceRef("svc"))
+		trigger.Spec.Filter = &eventingv1beta1.TriggerFilter{
+			Attributes: eventingv1beta1.TriggerFilterAttributes{"type": eventType},
+		}
+		return trigger
+	}
+	tests := []struct {
+		name        string
+		triggers    []eventingv1beta1.Trigger
+		wantCovered []string
+	}{{
+		name:        "uncovered type",
+		triggers:    []eventingv1beta1.Trigger{filtered("created", "dev.example.created")},
+		wantCovered: []string{"dev.example.created"},
+	}, {
+		name:     "no triggers",
+		triggers: nil,
+	}, {
+		name:        "any type",
+		triggers:    []eventingv1beta1.Trigger{newTrigger("default", "all", "default", *serviceRef("svc"))},
+		wantCovered: []string{"dev.example.created", "dev.example.deleted"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			g.AddEventType(newEventType("default", "created", "default", "dev.example.created"))
+			source := newSource("default", "ping", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default")
+			source.Status.CloudEventAttributes = []duckv1.CloudEventAttributes{{Type: "dev.example.deleted"}}
+			g.AddSource(source)
+			for _, trigger := range tt.triggers {
+				g.AddTrigger(trigger)
+			}
+
+			covered, all := g.FilterCoverage("eventing.knative.dev/broker/default")
+			if !reflect.DeepEqual(covered, tt.wantCovered) {
+				t.Errorf("covered = %q, want %q", covered, tt.wantCovered)
+			}
+			if want := []string{"dev.example.created", "dev.example.deleted"}; !reflect.DeepEqual(all, want) {
+				t.Errorf("all = %q, want %q", all, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/csv"
+	"sort"
+)
+
+// NodesCSV returns the tracked nodes as CSV with the columns key, kind,
+// namespace, name and shape, sorted by key.
+func (g *Graph) NodesCSV() []byte {
+	keys := make([]string, 0, len(g.nodes))
+	for k := range g.nodes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"key", "kind", "namespace", "name", "shape"})
+	for _, k := range keys {
+		info := g.info[k]
+		shape := g.nodes[k].Get("shape")
+		if shape == "" {
+			// Graphviz default.
+			shape = "ellipse"
+		}
+		_ = w.Write([]string{k, info.kind, info.namespace, info.name, shape})
+	}
+	w.Flush()
+	return buf.Bytes()
+}
+
+// EdgesCSV returns the drawn edges as CSV with the columns from, to,
+// relationship and direction, in the order the edges were drawn.
+func (g *Graph) EdgesCSV() []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"from", "to", "relationship", "direction"})
+	for _, e := range g.edges {
+		edge := g.edgeFor(e)
+		dir := e.Get("dir")
+		if dir == "" {
+			// Graphviz default for digraphs.
+			dir = "forward"
+		}
+		_ = w.Write([]string{edge.From, edge.To, edge.Relationship, dir})
+	}
+	w.Flush()
+	return buf.Bytes()
+}
@@ -0,0 +1,38 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNodesCSV(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "default"))
+
+	want := "key,kind,namespace,name,shape\n" +
+		"eventing.knative.dev/broker/default,Broker,default,default,oval\n"
+	if got := string(g.NodesCSV()); got != want {
+		t.Errorf("NodesCSV() = %q, want %q", got, want)
+	}
+}
+
+func TestEdgesCSV(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "default"))
+	g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+
+	lines := strings.Split(strings.TrimSpace(string(g.EdgesCSV())), "\n")
+	want := []string{
+		"from,to,relationship,direction",
+		"eventing.knative.dev/broker/default,eventing.knative.dev/trigger/t,trigger,forward",
+		"eventing.knative.dev/trigger/t,serving.knative.dev/service/svc,subscriber,both",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("EdgesCSV() = %q, want %q", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DetectCycles returns the loops in the event flow found by a depth first
+// search over the edges, such as a trigger whose subscriber replies back to
+// its own broker. Each cycle is the keys of its nodes in flow order, starting
+// from the smallest key, and is reported once.
+func (g *Graph) DetectCycles() [][]string {
+	adj := g.adjacency()
+	keys := make([]string, 0, len(g.nodes))
+	for k := range g.nodes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(keys))
+	seen := make(map[string]bool)
+	var cycles [][]string
+	var path []string
+	var visit func(k string)
+	visit = func(k string) {
+		state[k] = visiting
+		path = append(path, k)
+		for _, next := range adj[k] {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case visiting:
+				start := len(path) - 1
+				for path[start] != next {
+					start--
+				}
+				cycle := rotateToSmallest(path[start:])
+				id := fmt.Sprint(cycle)
+				if !seen[id] {
+					seen[id] = true
+					cycles = append(cycles, cycle)
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[k] = done
+	}
+	for _, k := range keys {
+		if state[k] == unvisited {
+			visit(k)
+		}
+	}
+	return cycles
+}
+
+// rotateToSmallest returns a copy of cycle starting from its smallest key.
+func rotateToSmallest(cycle []string) []string {
+	min := 0
+	for i, k := range cycle {
+		if k < cycle[min] {
+			min = i
+		}
+	}
+	return append(append([]string{}, cycle[min:]...), cycle[:min]...)
+}
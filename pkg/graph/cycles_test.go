@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDetectCycles(t *testing.T) {
+	// replyingService adds a service subscribed to by a trigger on broker
+	// that sends its events back to the broker.
+	replyingService := func(g *Graph, broker string) {
+		name := "to-" + broker
+		g.AddTrigger(newTrigger("default", name, broker, *serviceRef(name)))
+		g.AddKnService(newKnService("default", name, corev1.EnvVar{
+			Name:  "K_SINK",
+			Value: "http://broker-ingress.knative-eventing.svc.cluster.local/default/" + broker,
+		}))
+	}
+	loop := func(broker string) []string {
+		return []string{
+			"eventing.knative.dev/broker/" + broker,
+			"eventing.knative.dev/trigger/to-" + broker,
+			"serving.knative.dev/service/to-" + broker,
+		}
+	}
+	tests := []struct {
+		name  string
+		loops []string // brokers with a replying service
+		want  [][]string
+	}{{
+		name: "no loop",
+	}, {
+		name:  "loop",
+		loops: []string{"a"},
+		want:  [][]string{loop("a")},
+	}, {
+		name:  "two loops",
+		loops: []string{"b", "a"},
+		want:  [][]string{loop("a"), loop("b")},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			for _, broker := range []string{"a", "b"} {
+				g.AddBroker(newBroker("default", broker))
+				// Triggers out of the loops are not part of them.
+				g.AddTrigger(newTrigger("default", "other-"+broker, broker, *serviceRef("other")))
+			}
+			for _, broker := range tt.loops {
+				replyingService(g, broker)
+			}
+			if got := g.DetectCycles(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DetectCycles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
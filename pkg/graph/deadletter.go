@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"strings"
+
+	"github.com/tmc/dot"
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
+)
+
+// addDeadLetter draws an edge from the ingress node of a broker or channel to
+// its dead letter sink. Sinks not in the graph yet are drawn to once they are
+// added, so a chain of brokers and channels dead lettering into each other
+// reads as one path whatever order they are added in.
+func (g *Graph) addDeadLetter(from *dot.Node, ns string, delivery *eventingduckv1beta1.DeliverySpec) {
+	if delivery == nil || delivery.DeadLetterSink == nil {
+		return
+	}
+	dls := delivery.DeadLetterSink
+	target := g.destinationKey(ns, dls)
+	if dls.Ref == nil {
+		target = strings.TrimSuffix(dls.URI.String(), "/")
+		if key, ok := g.dnsToKey[target]; ok {
+			target = key
+		}
+	}
+	if to, ok := g.nodes[target]; ok {
+		g.drawDeadLetter(from, to)
+		return
+	}
+	g.pendingDeadLetters[target] = append(g.pendingDeadLetters[target], from)
+}
+
+// resolveDeadLetters draws the edges waiting on the resource with key, and
+// address dns, to be added.
+func (g *Graph) resolveDeadLetters(key, dns string) {
+	to := g.nodes[key]
+	for _, target := range []string{key, dns} {
+		if target == "" {
+			continue
+		}
+		for _, from := range g.pendingDeadLetters[target] {
+			g.drawDeadLetter(from, to)
+		}
+		delete(g.pendingDeadLetters, target)
+	}
+}
+
+// addSubscriberDeadLetter draws an edge from a subscription to the dead
+// letter sink its deliveries fall back to, drawing the sink like a
+// subscriber, or as an unknown sink if it is an address no resource serves.
+func (g *Graph) addSubscriberDeadLetter(from *dot.Node, ns string, delivery *eventingduckv1beta1.DeliverySpec) {
+	if delivery == nil || delivery.DeadLetterSink == nil {
+		return
+	}
+	dls := delivery.DeadLetterSink
+	var to *dot.Node
+	switch {
+	case dls.Ref != nil:
+		to = g.getOrCreateSubscriber(ns, dls)
+	case dls.URI != nil:
+		to = g.getOrCreateSink(dls.URI.String())
+	default:
+		return
+	}
+	if to == nil {
+		return
+	}
+	g.drawDeadLetter(from, to)
+}
+
+// drawDeadLetter draws a dead letter edge. It is kept out of the rainbow so
+// it always reads as an error path.
+func (g *Graph) drawDeadLetter(from, to *dot.Node) {
+	e := dot.NewEdge(from, to)
+	_ = e.Set("style", "dashed")
+	_ = e.Set("color", "red")
+	_ = e.Set("label", "dead-letter")
+	g.clipToClusters(e)
+	g.addEdge(e, relDeadLetter)
+}
@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Degree returns the number of edges into and out of the node with key,
+// counting only edges between tracked nodes.
+func (g *Graph) Degree(key string) (in, out int) {
+	for from, tos := range g.adjacency() {
+		for _, to := range tos {
+			if to == key {
+				in++
+			}
+		}
+		if from == key {
+			out = len(tos)
+		}
+	}
+	return in, out
+}
+
+// setDegreeLabels appends the out-degree to the label of every tracked node
+// with outgoing edges, replacing the one from an earlier render.
+func (g *Graph) setDegreeLabels() {
+	adj := g.adjacency()
+	for k, n := range g.nodes {
+		label := n.Get("label")
+		if strings.HasPrefix(label, "<") {
+			// HTML labels can not be appended to.
+			continue
+		}
+		if label == "" {
+			label = n.Name()
+		}
+		label = strings.TrimSuffix(label, g.degreeSuffix[n])
+		delete(g.degreeSuffix, n)
+		if out := len(adj[k]); out > 0 {
+			g.degreeSuffix[n] = fmt.Sprintf("\n(out: %d)", out)
+		}
+		_ = n.Set("label", label+g.degreeSuffix[n])
+	}
+}
@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDegree(t *testing.T) {
+	for _, triggers := range []int{0, 1, 3} {
+		t.Run(fmt.Sprintf("%d triggers", triggers), func(t *testing.T) {
+			g := New("default", WithDegreeLabels(true))
+			g.AddBroker(newBroker("default", "default"))
+			g.AddSource(newSource("default", "ping", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"))
+			for i := 0; i < triggers; i++ {
+				g.AddTrigger(newTrigger("default", fmt.Sprintf("t%d", i), "default", *serviceRef("svc")))
+			}
+
+			in, out := g.Degree("eventing.knative.dev/broker/default")
+			if in != 1 || out != triggers {
+				t.Errorf("Degree() = %d, %d, want 1, %d", in, out, triggers)
+			}
+
+			// Rendering twice must not repeat the suffix.
+			_ = g.String()
+			_ = g.String()
+			want := "Ingress"
+			if triggers > 0 {
+				want = fmt.Sprintf("Ingress\n(out: %d)", triggers)
+			}
+			if got := g.nodes["eventing.knative.dev/broker/default"].Get("label"); got != want {
+				t.Errorf("label = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/tmc/dot"
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
+)
+
+// maxPenWidth caps how thick retries can make a subscriber edge.
+const maxPenWidth = 5.0
+
+// setEdgeDelivery labels a subscriber edge with its delivery spec and makes
+// it thicker the more retries are configured.
+func (g *Graph) setEdgeDelivery(edge *dot.Edge, delivery *eventingduckv1beta1.DeliverySpec) {
+	if !g.deliveryDetails || delivery == nil {
+		return
+	}
+	if delivery.Retry != nil {
+		appendEdgeLabel(edge, fmt.Sprintf("retry: %d", *delivery.Retry))
+		_ = edge.Set("penwidth", strconv.FormatFloat(retryPenWidth(*delivery.Retry), 'f', -1, 64))
+	}
+	if delivery.BackoffPolicy != nil {
+		backoff := string(*delivery.BackoffPolicy)
+		if delivery.BackoffDelay != nil {
+			backoff = fmt.Sprintf("%s %s", backoff, *delivery.BackoffDelay)
+		}
+		appendEdgeLabel(edge, "backoff: "+backoff)
+	}
+}
+
+func retryPenWidth(retry int32) float64 {
+	width := 1 + 0.5*float64(retry)
+	if width > maxPenWidth {
+		return maxPenWidth
+	}
+	if width < 1 {
+		return 1
+	}
+	return width
+}
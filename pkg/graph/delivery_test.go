@@ -0,0 +1,115 @@
+package graph
+
+import (
+	"strconv"
+	"testing"
+
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func TestWithDeliveryDetails(t *testing.T) {
+	g := New("default", WithDeliveryDetails(true))
+	for _, b := range []struct {
+		name  string
+		retry int32
+	}{{"few", 1}, {"many", 5}} {
+		retry := b.retry
+		broker := newBroker("default", b.name)
+		broker.Spec.Delivery = &eventingduckv1beta1.DeliverySpec{Retry: &retry}
+		g.AddBroker(broker)
+		g.AddTrigger(newTrigger("default", "to-"+b.name, b.name, *serviceRef("svc")))
+	}
+
+	width := func(trigger string) float64 {
+		e := findEdge(t, g, "eventing.knative.dev/trigger/"+trigger, "serving.knative.dev/service/svc")
+		w, err := strconv.ParseFloat(e.Get("penwidth"), 64)
+		if err != nil {
+			t.Fatalf("penwidth of %s: %v", trigger, err)
+		}
+		return w
+	}
+	if few, many := width("to-few"), width("to-many"); many <= few {
+		t.Errorf("edge with 5 retries has penwidth %v, not thicker than %v for 1 retry", many, few)
+	}
+}
+
+func TestRetryPenWidth(t *testing.T) {
+	tests := []struct {
+		retry int32
+		want  float64
+	}{
+		{retry: -1, want: 1},
+		{retry: 0, want: 1},
+		{retry: 2, want: 2},
+		{retry: 100, want: maxPenWidth},
+	}
+	for _, tt := range tests {
+		if got := retryPenWidth(tt.retry); got != tt.want {
+			t.Errorf("retryPenWidth(%d) = %v, want %v", tt.retry, got, tt.want)
+		}
+	}
+}
+
+func TestDeadLetterSinks(t *testing.T) {
+	const (
+		broker = "eventing.knative.dev/broker/with-dls"
+		sub    = "messaging.knative.dev/subscription/sub"
+	)
+	tests := []struct {
+		name string
+		dls  *duckv1.Destination
+		want string
+		from []string
+	}{{
+		name: "ref",
+		dls:  serviceRef("dlq"),
+		want: "serving.knative.dev/service/dlq",
+		from: []string{broker, sub},
+	}, {
+		name: "broker address",
+		dls:  &duckv1.Destination{URI: mustURL("http://broker-ingress.knative-eventing.svc.cluster.local/default/default")},
+		want: "eventing.knative.dev/broker/default",
+		from: []string{broker, sub},
+	}, {
+		name: "service address",
+		dls:  &duckv1.Destination{URI: mustURL("http://dlq.default.svc.cluster.local")},
+		want: "serving.knative.dev/service/dlq",
+		from: []string{broker},
+	}, {
+		// Brokers only dead letter into resources in the graph.
+		name: "unknown address",
+		dls:  &duckv1.Destination{URI: mustURL("http://dlq.example.com/")},
+		want: "uri/http://dlq.example.com",
+		from: []string{sub},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			// eventing v1beta1 triggers deliver with their broker's
+			// delivery, so their dead letter sink is drawn from it.
+			b := newBroker("default", "with-dls")
+			b.Spec.Delivery = &eventingduckv1beta1.DeliverySpec{DeadLetterSink: tt.dls}
+			g.AddBroker(b)
+			g.AddTrigger(newTrigger("default", "t", "with-dls", *serviceRef("svc")))
+			g.AddInMemoryChannel(newChannel("default", "ch"))
+			s := newSubscription("default", "sub", "ch", serviceRef("svc"), nil)
+			s.Spec.Delivery = &eventingduckv1beta1.DeliverySpec{DeadLetterSink: tt.dls}
+			g.AddSubscription(s)
+			g.AddKnService(newKnService("default", "dlq"))
+
+			for _, from := range tt.from {
+				e := findEdge(t, g, from, tt.want)
+				if e.rel != relDeadLetter {
+					t.Errorf("edge from %s is a %q edge, want %q", from, e.rel, relDeadLetter)
+				}
+				for attr, want := range map[string]string{"style": "dashed", "color": "red", "label": "dead-letter"} {
+					if got := e.Get(attr); got != want {
+						t.Errorf("edge from %s has %s %q, want %q", from, attr, got, want)
+					}
+				}
+			}
+		})
+	}
+}
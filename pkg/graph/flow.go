@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"github.com/tmc/dot"
+)
+
+// FlowOrder returns the ids of the edges events take from the node with
+// fromKey, grouped by how many hops from it they are, for viewers that
+// animate an event spreading through the rendered graph. Each edge is listed
+// once, at the first hop it is reached, in the order it was drawn. It
+// returns nil if there is no node with fromKey.
+func (g *Graph) FlowOrder(fromKey string) [][]string {
+	from, ok := g.nodes[fromKey]
+	if !ok {
+		return nil
+	}
+	out := make(map[*dot.Node][]*edge)
+	for _, e := range g.edges {
+		out[e.Source()] = append(out[e.Source()], e)
+	}
+
+	var levels [][]string
+	seen := map[*dot.Node]bool{from: true}
+	for frontier := []*dot.Node{from}; len(frontier) > 0; {
+		var level []string
+		var next []*dot.Node
+		for _, n := range frontier {
+			for _, e := range out[n] {
+				level = append(level, e.Get("id"))
+				if dst := e.Destination(); !seen[dst] {
+					seen[dst] = true
+					next = append(next, dst)
+				}
+			}
+		}
+		if len(level) > 0 {
+			levels = append(levels, level)
+		}
+		frontier = next
+	}
+	return levels
+}
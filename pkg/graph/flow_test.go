@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlowOrder(t *testing.T) {
+	const (
+		source = "sources.knative.dev/pingsource/ping"
+		broker = "eventing.knative.dev/broker/default"
+	)
+	sink := "sources.knative.dev/pingsource/ping->eventing.knative.dev/broker/default:sink"
+	triggers := []string{
+		"eventing.knative.dev/broker/default->eventing.knative.dev/trigger/t:trigger",
+		"eventing.knative.dev/broker/default->eventing.knative.dev/trigger/u:trigger",
+	}
+	subscribers := []string{
+		"eventing.knative.dev/trigger/t->serving.knative.dev/service/svc:subscriber",
+		"eventing.knative.dev/trigger/u->serving.knative.dev/service/svc:subscriber",
+	}
+	tests := []struct {
+		name string
+		from string
+		want [][]string
+	}{{
+		name: "from source",
+		from: source,
+		want: [][]string{{sink}, triggers, subscribers},
+	}, {
+		name: "from broker",
+		from: broker,
+		want: [][]string{triggers, subscribers},
+	}, {
+		name: "from trigger",
+		from: "eventing.knative.dev/trigger/u",
+		want: [][]string{subscribers[1:]},
+	}, {
+		name: "from subscriber",
+		from: "serving.knative.dev/service/svc",
+	}, {
+		name: "missing",
+		from: "eventing.knative.dev/broker/gone",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			// Both triggers send to the same service, which is only
+			// reached once but whose edges are each listed.
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+			g.AddTrigger(newTrigger("default", "u", "default", *serviceRef("svc")))
+			g.AddSource(newSource("default", "ping", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"))
+
+			if got := g.FlowOrder(tt.from); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FlowOrder(%s) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
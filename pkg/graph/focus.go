@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"github.com/tmc/dot"
+)
+
+// Focus dims every node and edge that is not reachable from or to the node
+// with the given key, leaving the focused subtree fully colored.
+func (g *Graph) Focus(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.nodes[key]; !ok {
+		return
+	}
+
+	keep := map[string]bool{key: true}
+	walk(key, g.adjacency(), keep)
+	walk(key, reverse(g.adjacency()), keep)
+
+	keys := make(map[*dot.Node]string, len(g.nodes))
+	for k, n := range g.nodes {
+		keys[n] = k
+		if !keep[k] {
+			dimNode(n)
+		}
+	}
+	for _, e := range g.edges {
+		if !keep[keys[e.Source()]] || !keep[keys[e.Destination()]] {
+			dimEdge(e.Edge)
+		}
+	}
+}
+
+// walk marks every key reachable from key in adj as seen.
+func walk(key string, adj map[string][]string, seen map[string]bool) {
+	for _, next := range adj[key] {
+		if !seen[next] {
+			seen[next] = true
+			walk(next, adj, seen)
+		}
+	}
+}
+
+func reverse(adj map[string][]string) map[string][]string {
+	rev := make(map[string][]string, len(adj))
+	for from, tos := range adj {
+		for _, to := range tos {
+			rev[to] = append(rev[to], from)
+		}
+	}
+	return rev
+}
+
+func dimNode(node *dot.Node) {
+	_ = node.Set("color", "gray80")
+	_ = node.Set("fontcolor", "gray60")
+	_ = node.Set("fillcolor", "gray95")
+}
+
+func dimEdge(edge *dot.Edge) {
+	_ = edge.Set("color", "gray80")
+	_ = edge.Set("fontcolor", "gray60")
+}
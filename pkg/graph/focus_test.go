@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestFocus(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "a"))
+	g.AddTrigger(newTrigger("default", "ta", "a", *serviceRef("svc-a")))
+	g.AddBroker(newBroker("default", "b"))
+	g.AddTrigger(newTrigger("default", "tb", "b", *serviceRef("svc-b")))
+
+	g.Focus("eventing.knative.dev/trigger/ta")
+
+	tests := []struct {
+		key    string
+		dimmed bool
+	}{
+		{key: "eventing.knative.dev/broker/a", dimmed: false},
+		{key: "eventing.knative.dev/trigger/ta", dimmed: false},
+		{key: "serving.knative.dev/service/svc-a", dimmed: false},
+		{key: "eventing.knative.dev/broker/b", dimmed: true},
+		{key: "eventing.knative.dev/trigger/tb", dimmed: true},
+		{key: "serving.knative.dev/service/svc-b", dimmed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			n := g.nodes[tt.key]
+			if got := n.Get("fillcolor") == "gray95" && n.Get("color") == "gray80"; got != tt.dimmed {
+				t.Errorf("dimmed = %v, want %v", got, tt.dimmed)
+			}
+		})
+	}
+
+	if got := findEdge(t, g, "eventing.knative.dev/trigger/tb", "serving.knative.dev/service/svc-b").Get("color"); got != "gray80" {
+		t.Errorf("edge out of the subtree has color %q, want gray80", got)
+	}
+	if got := findEdge(t, g, "eventing.knative.dev/trigger/ta", "serving.knative.dev/service/svc-a").Get("color"); got == "gray80" {
+		t.Error("edge in the subtree is dimmed")
+	}
+}
@@ -2,240 +2,1187 @@ package graph
 
 import (
 	"fmt"
+	"net/url"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	flowsv1beta1 "knative.dev/eventing/pkg/apis/flows/v1beta1"
 	messagingv1beta1 "knative.dev/eventing/pkg/apis/messaging/v1beta1"
 	"knative.dev/pkg/apis"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/tmc/dot"
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
 	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	duckv1beta1 "knative.dev/pkg/apis/duck/v1beta1"
+	"knative.dev/pkg/tracker"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
 
 	"github.com/n3wscott/graph/pkg/knative"
 )
 
+// Graph is the event flow graph of the resources added to it.
+//
+// The Add methods, LoadKnService, Delete, RemoveNode, Upsert, Plan, Focus,
+// SetOpacity, ApplyPositions, IncludeOnly, String and WriteTo can be called
+// from several goroutines, for example one per informer while another
+// renders. Each holds a single lock over the whole graph while it runs, so
+// producers take turns rather than add in parallel; adding is cheap next to
+// listing, so the lock is rarely contended, but a render holds it for as
+// long as the graph takes to print. The other methods read the graph
+// without the lock and expect it to be done.
 type Graph struct {
 	*dot.Graph
+	mu        *sync.Mutex // guards the whole graph, see above
+	ns        string      // namespace named in the default title
 	nodes     map[string]*dot.Node
+	keys      map[*dot.Node]string // node keys by node, the reverse of nodes
 	subgraphs map[string]*dot.SubGraph
 	dnsToKey  map[string]string // maps domain name to node key
+	info      map[string]nodeInfo
+	edges     []*edge
+	parts     map[string][]string // keys of the step, branch and reply nodes drawn for a resource
+
+	order         []*dot.Node                     // every node, in the order added
+	parent        map[*dot.Node]*dot.SubGraph     // cluster holding a node, nil for root
+	clusters      []*dot.SubGraph                 // every cluster, in the order added
+	clusterParent map[*dot.SubGraph]*dot.SubGraph // cluster holding a cluster, nil for root
+	clusterNames  map[string]int                  // clusters named so far per name prefix
+	edgeIDs       map[string]int                  // number of edges drawn per edge id
+
+	keyFunc KeyFunc
+
+	maxClusterDepth int
+	flattened       map[*dot.SubGraph]*dot.SubGraph // clusters past maxClusterDepth, to the cluster holding their nodes
 
 	edgeCount   int
 	rainbowEdge bool
+	colors      []string // rainbow edge colors, in order
+
+	latencyLabels     bool
+	concurrencyLabels bool
+	authInfo          bool
+	clusterLabel      func(kind, name, dns string) string
+	ingressPorts      bool
+	tooltips          bool
+	maxNodes          int
+	deletionState     bool
+
+	annotationTooltips []string
+	topologyOnly       bool
+	includeOnly        []schema.GroupKind // kinds drawn, all when empty
+	orphanCluster      bool
+
+	highlightSources    bool
+	resolvedURLs        bool
+	compactSourceLabels bool
+	triggerTable        bool
+	triggerRows         map[string][]string // rendered table rows by table key
+	deliveryDetails     bool
+	brokerDelivery      map[string]*eventingduckv1beta1.DeliverySpec // by broker key
+	pendingDeadLetters  map[string][]*dot.Node                       // ingress nodes by the key or dns of a dead letter sink not added yet
+	sequenceSteps       map[string][]string                          // node names in order by sequence key
+	sequenceRanked      map[*dot.SubGraph]bool                       // sequence clusters already aligned
+	alignTriggers       bool
+	triggersRanked      map[*dot.SubGraph]int      // number of triggers last ranked per cluster
+	brokerTypes         map[string][]string        // known event types by broker key
+	brokerFilters       map[string][]string        // trigger type filters by broker key, "" for any
+	triggerFilters      map[string][]triggerFilter // trigger filters by broker key
+	readinessColors     bool
+	readinessPalette    readinessPalette
+	sinkEnvNames        map[string]bool
+	unknownPrefixes     map[string]string // placeholder node prefix by kind
+	notReady            map[*dot.Node]bool
+	defaultOpacity      float64
+	opacity             map[*dot.Node]float64 // fill opacity set per node
+	fills               map[*dot.Node]fill    // fill colors before and after the last render applied opacity
+	ageGradient         bool
+	ageFills            map[*dot.Node]string // fill colors set by the last render from age
+
+	warnings     []string
+	truncated    bool
+	errorBanners bool
+
+	groupSourcesBySink bool
+	sourceGroups       map[string]*dot.SubGraph // source clusters by sink dns
+
+	degreeLabels bool
+	degreeSuffix map[*dot.Node]string // out-degree appended to labels by the last render
+
+	countsInTitle bool
+	titleSuffix   string // counts appended to the label by the last render
+
+	legend []*dot.Edge // legend sample edges, kept out of the event flow
+
+	timestampNode bool
+	timestamp     *dot.Node // render time caption, kept out of the event flow
+
+	partOfGrouping bool
+	partOfGroups   map[string]*dot.SubGraph // application clusters by part-of label
+
+	namespaceClusters bool
+	namespaceGroups   map[string]*dot.SubGraph // namespace clusters by namespace
+
+	groupTriggersBySubscriber bool
+	triggerGroups             map[string]*dot.SubGraph // trigger clusters by broker and subscriber
+	ports                     map[string]int           // next compass point per ingress key
 }
 
-func New(ns string) *Graph {
+func New(ns string, opts ...Option) *Graph {
 	g := dot.NewGraph("G")
 	_ = g.Set("tooltip", "Graph View")
 	_ = g.Set("shape", "box")
 	_ = g.Set("label", "Triggers in "+ns)
 	_ = g.Set("rankdir", "LR")
-
-	//_ = g.Set("compound", "true")
+	// Lets edges into brokers and channels end at their cluster border.
+	_ = g.Set("compound", "true")
 
 	graph := &Graph{
-		Graph:       g,
-		nodes:       make(map[string]*dot.Node),
-		subgraphs:   make(map[string]*dot.SubGraph),
-		dnsToKey:    make(map[string]string),
-		rainbowEdge: true,
+		Graph:              g,
+		mu:                 new(sync.Mutex),
+		ns:                 ns,
+		nodes:              make(map[string]*dot.Node),
+		keys:               make(map[*dot.Node]string),
+		subgraphs:          make(map[string]*dot.SubGraph),
+		dnsToKey:           make(map[string]string),
+		info:               make(map[string]nodeInfo),
+		parts:              make(map[string][]string),
+		parent:             make(map[*dot.Node]*dot.SubGraph),
+		clusterParent:      make(map[*dot.SubGraph]*dot.SubGraph),
+		clusterNames:       make(map[string]int),
+		flattened:          make(map[*dot.SubGraph]*dot.SubGraph),
+		rainbowEdge:        true,
+		colors:             colors,
+		clusterLabel:       defaultClusterLabel,
+		sourceGroups:       make(map[string]*dot.SubGraph),
+		triggerGroups:      make(map[string]*dot.SubGraph),
+		partOfGroups:       make(map[string]*dot.SubGraph),
+		namespaceGroups:    make(map[string]*dot.SubGraph),
+		triggerRows:        make(map[string][]string),
+		brokerDelivery:     make(map[string]*eventingduckv1beta1.DeliverySpec),
+		pendingDeadLetters: make(map[string][]*dot.Node),
+		brokerTypes:        make(map[string][]string),
+		sequenceSteps:      make(map[string][]string),
+		sequenceRanked:     make(map[*dot.SubGraph]bool),
+		triggersRanked:     make(map[*dot.SubGraph]int),
+		brokerFilters:      make(map[string][]string),
+		triggerFilters:     make(map[string][]triggerFilter),
+		readinessColors:    true,
+		readinessPalette:   defaultReadinessPalette,
+		defaultOpacity:     1,
+		opacity:            make(map[*dot.Node]float64),
+		fills:              make(map[*dot.Node]fill),
+		ageFills:           make(map[*dot.Node]string),
+		sinkEnvNames:       map[string]bool{"SINK": true, "TARGET": true, "K_SINK": true},
+		unknownPrefixes: map[string]string{
+			"Broker":      "Unknown Broker",
+			"Sink":        "Unknown Sink",
+			"Destination": "Unknown Destination",
+		},
+		notReady:     make(map[*dot.Node]bool),
+		degreeSuffix: make(map[*dot.Node]string),
+		edgeIDs:      make(map[string]int),
+		ports:        make(map[string]int),
+	}
+
+	for _, opt := range opts {
+		opt(graph)
 	}
 
 	return graph
 }
 
+// nodeInfo describes the resource a node was drawn for.
+type nodeInfo struct {
+	kind       string
+	apiVersion string
+	namespace  string
+	name       string
+	created    time.Time
+}
+
+func objectInfo(kind, apiVersion string, meta metav1.ObjectMeta) nodeInfo {
+	return nodeInfo{
+		kind:       kind,
+		apiVersion: apiVersion,
+		namespace:  meta.Namespace,
+		name:       meta.Name,
+		created:    meta.CreationTimestamp.Time,
+	}
+}
+
+// Relationships an edge can represent between its endpoints.
+const (
+	relSubscriber = "subscriber"
+	relReply      = "reply"
+	relSink       = "sink"
+	relTrigger    = "trigger"
+	relEventType  = "eventtype"
+	relStep       = "step"
+	relDeadLetter = "deadletter"
+	relOwner      = "owner"
+	relSubject    = "subject"
+)
+
+// edge is a dot edge along with the relationship it represents.
+type edge struct {
+	*dot.Edge
+	rel string
+}
+
+// AddNode adds the node to the root of the graph.
+func (g *Graph) AddNode(n *dot.Node) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addNodeTo(nil, n)
+}
+
+// Nodes returns the tracked nodes by key. The map is a copy, but the nodes
+// are the ones drawn.
+func (g *Graph) Nodes() map[string]*dot.Node {
+	nodes := make(map[string]*dot.Node, len(g.nodes))
+	for k, n := range g.nodes {
+		nodes[k] = n
+	}
+	return nodes
+}
+
+// Edges returns the drawn edges by the keys of their endpoints, in the order
+// they were drawn.
+func (g *Graph) Edges() []Edge {
+	edges := make([]Edge, 0, len(g.edges))
+	for _, e := range g.edges {
+		edges = append(edges, g.edgeFor(e))
+	}
+	return edges
+}
+
+// AddSubgraph adds the cluster to the root of the graph.
+func (g *Graph) AddSubgraph(sg *dot.SubGraph) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addCluster(nil, sg)
+}
+
+// addNodeTo adds the node to the cluster sg, or to the root if sg is nil,
+// keeping track of where it was placed.
+func (g *Graph) addNodeTo(sg *dot.SubGraph, n *dot.Node) {
+	if into, ok := g.flattened[sg]; ok {
+		_ = n.Set("group", sg.Name())
+		sg = into
+	}
+	g.order = append(g.order, n)
+	if sg == nil {
+		g.Graph.AddNode(n)
+		return
+	}
+	g.parent[n] = sg
+	sg.AddNode(n)
+}
+
+// addCluster nests the cluster sg in parent, or in the root if parent is
+// nil, keeping track of where it was placed.
+func (g *Graph) addCluster(parent, sg *dot.SubGraph) {
+	if into, ok := g.flattened[parent]; ok {
+		parent = into
+	}
+	if g.maxClusterDepth > 0 && parent != nil && g.clusterDepth(parent) >= g.maxClusterDepth {
+		// Too deep, its nodes go in parent instead.
+		g.flattened[sg] = parent
+		return
+	}
+	g.clusters = append(g.clusters, sg)
+	if parent == nil {
+		g.Graph.AddSubgraph(sg)
+		return
+	}
+	g.clusterParent[sg] = parent
+	parent.AddSubgraph(sg)
+}
+
+// newCluster returns a cluster named by prefix and a number, counting the
+// clusters named with prefix before so removed ones are not named again.
+func (g *Graph) newCluster(prefix string) *dot.SubGraph {
+	n := g.clusterNames[prefix]
+	g.clusterNames[prefix]++
+	return dot.NewSubgraph(fmt.Sprintf("%s_%d", prefix, n))
+}
+
+// clusterDepth returns how deep sg is nested, 1 for a cluster in the root.
+func (g *Graph) clusterDepth(sg *dot.SubGraph) int {
+	depth := 0
+	for ; sg != nil; sg = g.clusterParent[sg] {
+		depth++
+	}
+	return depth
+}
+
+// AddEdge adds the edge to the underlying dot graph and records it so the
+// event flow can be walked later.
+func (g *Graph) AddEdge(e *dot.Edge) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addEdge(e, "")
+}
+
+func (g *Graph) addEdge(e *dot.Edge, rel string) {
+	id := edgeID(g.keyOf(e.Source()), g.keyOf(e.Destination()), rel)
+	if n := g.edgeIDs[id]; n > 0 {
+		g.edgeIDs[id]++
+		id = fmt.Sprintf("%s-%d", id, n)
+	} else {
+		g.edgeIDs[id] = 1
+	}
+	_ = e.Set("id", id)
+
+	if e.Source() == e.Destination() {
+		// Keep self-loops small and make them stand out.
+		_ = e.Set("tailport", "ne")
+		_ = e.Set("headport", "se")
+		_ = e.Set("style", "dashed")
+		_ = e.Set("color", "red")
+		g.warn("%s references itself", g.keyOf(e.Source()))
+	}
+
+	g.edges = append(g.edges, &edge{Edge: e, rel: rel})
+	g.Graph.AddEdge(e)
+}
+
+// clipToClusters ends e at the border of the cluster drawn for the resource
+// it points to, and starts it at the border of the one it leaves, rather than
+// at their ingress nodes. Clusters holding the other end are left alone, as
+// graphviz cannot clip to them.
+func (g *Graph) clipToClusters(e *dot.Edge) {
+	if sg, ok := g.drawnCluster(e.Destination()); ok && !g.inCluster(e.Source(), sg) {
+		_ = e.Set("lhead", sg.Name())
+	}
+	if sg, ok := g.drawnCluster(e.Source()); ok && !g.inCluster(e.Destination(), sg) {
+		_ = e.Set("ltail", sg.Name())
+	}
+}
+
+// drawnCluster returns the cluster drawn for the resource of node n, if it
+// has one that was not flattened away.
+func (g *Graph) drawnCluster(n *dot.Node) (*dot.SubGraph, bool) {
+	sg, ok := g.subgraphs[g.keyOf(n)]
+	if !ok || g.parent[n] != sg {
+		return nil, false
+	}
+	return sg, true
+}
+
+// inCluster reports whether n is placed in sg or a cluster nested in it.
+func (g *Graph) inCluster(n *dot.Node, sg *dot.SubGraph) bool {
+	for c := g.parent[n]; c != nil; c = g.clusterParent[c] {
+		if c == sg {
+			return true
+		}
+	}
+	return false
+}
+
+// track records node as the node for the resource with key.
+func (g *Graph) track(key string, node *dot.Node) {
+	g.nodes[key] = node
+	g.keys[node] = key
+}
+
+// keyOf returns the key the node is tracked under, falling back to the node
+// name for nodes that are not tracked.
+func (g *Graph) keyOf(node *dot.Node) string {
+	if k, ok := g.keys[node]; ok {
+		return k
+	}
+	return node.Name()
+}
+
+func edgeID(from, to, rel string) string {
+	if rel == "" {
+		return strings.ToLower(fmt.Sprintf("%s->%s", from, to))
+	}
+	return strings.ToLower(fmt.Sprintf("%s->%s:%s", from, to, rel))
+}
+
+// adjacency returns the outgoing node keys for each node key, following the
+// recorded edges. Edges to nodes that are not tracked by key are skipped.
+func (g *Graph) adjacency() map[string][]string {
+	adj := make(map[string][]string, len(g.nodes))
+	for _, e := range g.edges {
+		from, ok := g.keys[e.Source()]
+		if !ok {
+			continue
+		}
+		to, ok := g.keys[e.Destination()]
+		if !ok {
+			continue
+		}
+		adj[from] = append(adj[from], to)
+	}
+	return adj
+}
+
+// Compass points handed out to edges leaving an ingress node, favoring the
+// side facing the rest of the graph.
+var compassPoints = []string{"e", "ne", "se", "n", "s", "nw", "sw", "w"}
+
+func (g *Graph) nextPort(key string) string {
+	port := compassPoints[g.ports[key]%len(compassPoints)]
+	g.ports[key]++
+	return port
+}
+
 func (g *Graph) newEdge(src, dst *dot.Node) *dot.Edge {
 	e := dot.NewEdge(src, dst)
 	if g.rainbowEdge {
-		color := colors[g.edgeCount%len(colors)]
+		color := g.colors[g.edgeCount%len(g.colors)]
 		_ = e.Set("color", color)
 		g.edgeCount++
 	}
 	return e
 }
 
-//func (g *Graph) AddChannel(channel eventingv1beta1.Channel) {
-//	ck := channelKey(channel.Name)
-//	uri := channel.Status.Address.GetURL()
-//	dns := strings.TrimSuffix((&uri).String(), "/")
-//	cn := dot.NewNode("Channel " + channel.Name)
-//
-//	_ = cn.Set("URL", knative.ToYamlViewURL(channel.Name, channel.Kind, channel.APIVersion))
-//	setNodeShapeForKind(cn, channel.Kind, channel.APIVersion)
-//	setNodeColorForStatus(cn, channel.Status.Status)
-//
-//	_ = cn.Set("shape", "oval") // TODO move to setNodeShapeForKind
-//	_ = cn.Set("label", "Ingress")
-//
-//	g.nodes[ck] = cn
-//	g.dnsToKey[dns] = ck
-//
-//	cg := dot.NewSubgraph(fmt.Sprintf("cluster_%d", len(g.subgraphs)))
-//	_ = cg.Set("label", fmt.Sprintf("Channel %s\n%s", channel.Name, dns))
-//	g.subgraphs[ck] = cg
-//	cg.AddNode(cn)
-//	g.AddSubgraph(cg)
-//}
+// ChannelShapes maps the kind of the channel backing a Channel to the shape
+// the Channel is drawn with. The version of the keys is left empty. Channels
+// backed by other kinds are drawn as ovals.
+var ChannelShapes = map[schema.GroupVersionKind]string{
+	{Group: "messaging.knative.dev", Kind: "InMemoryChannel"}: "oval",
+	{Group: "messaging.knative.dev", Kind: "KafkaChannel"}:    "cylinder",
+	{Group: "messaging.knative.dev", Kind: "NatssChannel"}:    "doubleoctagon",
+}
+
+func channelShape(gvk schema.GroupVersionKind) string {
+	gvk.Version = ""
+	if shape, ok := ChannelShapes[gvk]; ok {
+		return shape
+	}
+	return "oval"
+}
+
+func (g *Graph) AddChannel(channel messagingv1beta1.Channel) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addChannel(channel)
+}
+
+func (g *Graph) addChannel(channel messagingv1beta1.Channel) {
+	if g.full() {
+		return
+	}
+
+	ck := g.channelKey(channel.Namespace, channel.Name)
+	dns := ""
+	if channel.Status.Address != nil {
+		dns = strings.TrimSuffix(channel.Status.Address.URL.String(), "/")
+	}
+	cn := g.newNode(channel.Namespace, "Channel "+channel.Name)
+
+	_ = cn.Set("URL", knative.ToYamlViewURL(channel.Name, channel.Kind, channel.APIVersion))
+	g.setNodeColorForStatus(cn, channel.Status.Status)
+	g.setNodeForMeta(cn, channel.ObjectMeta)
 
-// TODO: add channel ducktype.
+	_ = cn.Set("shape", "oval")
+	_ = cn.Set("label", "Ingress")
+
+	// The template names the backing kind, or else the status once the
+	// backing channel was created.
+	var backing schema.GroupVersionKind
+	if tmpl := channel.Spec.ChannelTemplate; tmpl != nil {
+		backing = tmpl.GroupVersionKind()
+	} else if ref := channel.Status.Channel; ref != nil {
+		backing = schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind)
+	}
+	label := g.clusterLabel("Channel", channel.Name, dns)
+	if backing.Kind != "" {
+		_ = cn.Set("shape", channelShape(backing))
+		label = fmt.Sprintf("%s\n(%s)", label, backing.Kind)
+	}
+
+	g.track(ck, cn)
+	g.info[ck] = objectInfo(channel.Kind, channel.APIVersion, channel.ObjectMeta)
+	if dns != "" {
+		g.dnsToKey[dns] = ck
+	}
+
+	cg := g.newCluster("cluster")
+	_ = cg.Set("label", escapeLabel(label))
+	g.subgraphs[ck] = cg
+	g.addNodeTo(cg, cn)
+	g.addCluster(g.partOfGroup(channel.ObjectMeta), cg)
+
+	g.addDeadLetter(cn, channel.Namespace, channel.Spec.Delivery)
+	g.resolveDeadLetters(ck, dns)
+}
 
 func (g *Graph) AddInMemoryChannel(channel messagingv1beta1.InMemoryChannel) {
-	ck := inMemoryChannelKey(channel.Name)
-	uri := channel.Status.Address.URL
-	dns := strings.TrimSuffix(uri.String(), "/")
-	cn := dot.NewNode("InMemoryChannel " + channel.Name)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addInMemoryChannel(channel)
+}
+
+func (g *Graph) addInMemoryChannel(channel messagingv1beta1.InMemoryChannel) {
+	if g.full() {
+		return
+	}
+
+	ck := g.inMemoryChannelKey(channel.Namespace, channel.Name)
+	dns := ""
+	if channel.Status.Address != nil {
+		dns = strings.TrimSuffix(channel.Status.Address.URL.String(), "/")
+	}
+	if dns == "" {
+		g.malformed("%s has no address", ck)
+	}
+	cn := g.newNode(channel.Namespace, "InMemoryChannel "+channel.Name)
 
 	_ = cn.Set("URL", knative.ToYamlViewURL(channel.Name, channel.Kind, channel.APIVersion))
 	setNodeShapeForKind(cn, channel.Kind, channel.APIVersion)
-	setNodeColorForStatus(cn, channel.Status.Status)
+	g.setNodeColorForStatus(cn, channel.Status.Status)
+	g.setNodeForMeta(cn, channel.ObjectMeta)
 
-	_ = cn.Set("shape", "oval") // TODO move to setNodeShapeForKind
+	_ = cn.Set("shape", channelShape(schema.GroupVersionKind{Group: "messaging.knative.dev", Kind: "InMemoryChannel"}))
 	_ = cn.Set("label", "Ingress")
 
-	g.nodes[ck] = cn
-	g.dnsToKey[dns] = ck
+	g.track(ck, cn)
+	g.info[ck] = objectInfo(channel.Kind, channel.APIVersion, channel.ObjectMeta)
+	if dns != "" {
+		g.dnsToKey[dns] = ck
+	}
 
-	cg := dot.NewSubgraph(fmt.Sprintf("cluster_%d", len(g.subgraphs)))
-	_ = cg.Set("label", fmt.Sprintf("InMemoryChannel %s\n%s", channel.Name, dns))
+	cg := g.newCluster("cluster")
+	_ = cg.Set("label", escapeLabel(g.clusterLabel("InMemoryChannel", channel.Name, dns)))
 	g.subgraphs[ck] = cg
-	cg.AddNode(cn)
-	g.AddSubgraph(cg)
+	g.addNodeTo(cg, cn)
+	g.addCluster(g.partOfGroup(channel.ObjectMeta), cg)
+
+	g.addDeadLetter(cn, channel.Namespace, channel.Spec.Delivery)
+	g.resolveDeadLetters(ck, dns)
 }
 
 func (g *Graph) AddSubscription(subscription messagingv1beta1.Subscription) {
-	sk := subscriptionKey(subscription.Name)
-	sn := dot.NewNode("Subscription " + subscription.Name)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addSubscription(subscription)
+}
+
+func (g *Graph) addSubscription(subscription messagingv1beta1.Subscription) {
+	if g.full() {
+		return
+	}
+
+	sk := g.subscriptionKey(subscription.Namespace, subscription.Name)
+	sn := g.newNode(subscription.Namespace, "Subscription "+subscription.Name)
+	if kind := subscription.Spec.Channel.Kind; kind != "" {
+		_ = sn.Set("label", fmt.Sprintf("Subscription %s\non %s", subscription.Name, kind))
+	}
 	_ = sn.Set("URL", knative.ToYamlViewURL(subscription.Name, subscription.Kind, subscription.APIVersion))
-	setNodeColorForStatus(sn, subscription.Status.Status)
+	g.setNodeColorForStatus(sn, subscription.Status.Status)
+	g.setNodeForMeta(sn, subscription.ObjectMeta)
 
-	ck := gvkKey(subscription.Spec.Channel.GroupVersionKind(), subscription.Spec.Channel.Name)
+	ck := g.subscribableKey(subscription.Spec.Channel.GroupVersionKind(), subscription.Namespace, subscription.Spec.Channel.Name)
 	if cg, ok := g.subgraphs[ck]; !ok {
-		g.AddNode(sn)
+		g.addNodeTo(g.partOfGroup(subscription.ObjectMeta), sn)
 	} else {
-		cg.AddNode(sn)
+		g.addNodeTo(cg, sn)
 	}
-	g.nodes[sk] = sn
+	g.track(sk, sn)
+	g.info[sk] = objectInfo(subscription.Kind, subscription.APIVersion, subscription.ObjectMeta)
+
+	g.addSubscriberDeadLetter(sn, subscription.Namespace, subscription.Spec.Delivery)
+
+	sub := g.getOrCreateSubscriber(subscription.Namespace, subscription.Spec.Subscriber)
+	rep := g.getOrCreateReply(subscription.Namespace, subscription.Spec.Reply)
 
-	if sub := g.getOrCreateSubscriber(subscription.Spec.Subscriber); sub != nil {
+	if sub != nil && sub == rep {
+		// Subscriber and reply are the same target, merge them into one edge.
 		e := dot.NewEdge(sn, sub)
 		_ = e.Set("dir", "both")
-		setEdgeColorForStatus(e, subscription.Status.Status)
-		g.AddEdge(e)
+		_ = e.Set("label", "subscribe+reply")
+		g.setEdgeColorForStatus(e, subscription.Status.Status)
+		g.setEdgeDelivery(e, subscription.Spec.Delivery)
+		g.setResolvedURL(e, subscription.Spec.Subscriber, subscription.Status.PhysicalSubscription.SubscriberURI)
+		g.setAuthInfo(e, subscription.ObjectMeta)
+		g.clipToClusters(e)
+		g.addEdge(e, relSubscriber)
+		return
 	}
 
-	if rep := g.getOrCreateReply(subscription.Spec.Reply); rep != nil {
+	if sub != nil {
+		e := dot.NewEdge(sn, sub)
+		_ = e.Set("dir", "both")
+		g.setEdgeColorForStatus(e, subscription.Status.Status)
+		g.setEdgeDelivery(e, subscription.Spec.Delivery)
+		g.setResolvedURL(e, subscription.Spec.Subscriber, subscription.Status.PhysicalSubscription.SubscriberURI)
+		g.setAuthInfo(e, subscription.ObjectMeta)
+		g.clipToClusters(e)
+		g.addEdge(e, relSubscriber)
+	}
+
+	if rep != nil {
 		e := g.newEdge(sn, rep)
 		_ = e.Set("dir", "forward")
-		g.AddEdge(e)
+		// Replies into a channel chain onto that channel's cluster.
+		g.clipToClusters(e)
+		g.addEdge(e, relReply)
 	}
 }
 
 func (g *Graph) AddBroker(broker eventingv1beta1.Broker) {
-	key := brokerKey(broker.Name)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addBroker(broker)
+}
+
+func (g *Graph) addBroker(broker eventingv1beta1.Broker) {
+	if g.full() {
+		return
+	}
+
+	key := g.brokerKey(broker.Namespace, broker.Name)
 	uri := broker.Status.Address.URL
 	dns := strings.TrimSuffix(uri.String(), "/")
-	bn := dot.NewNode("Broker " + dns)
-	_ = bn.Set("shape", "oval")
+	name := dns
+	if dns == "" {
+		g.malformed("%s has no address", key)
+		name = broker.Name
+	}
+	bn := g.newNode(broker.Namespace, "Broker "+name)
+	_ = bn.Set("shape", brokerShape(broker.Annotations[BrokerClassAnnotation]))
 	_ = bn.Set("label", "Ingress")
 	_ = bn.Set("URL", knative.ToYamlViewURL(broker.Name, broker.Kind, broker.APIVersion))
-	setNodeColorForStatus(bn, broker.Status.Status)
+	g.setNodeColorForStatus(bn, broker.Status.Status)
+	g.setNodeForMeta(bn, broker.ObjectMeta)
 
-	g.nodes[key] = bn
-	g.dnsToKey[dns] = key
+	g.track(key, bn)
+	g.info[key] = objectInfo(broker.Kind, broker.APIVersion, broker.ObjectMeta)
+	if dns != "" {
+		g.dnsToKey[dns] = key
+	}
+	g.brokerDelivery[key] = broker.Spec.Delivery
 
-	bg := dot.NewSubgraph(fmt.Sprintf("cluster_%d", len(g.subgraphs)))
-	_ = bg.Set("label", fmt.Sprintf("Broker %s\n%s", broker.Name, dns))
+	bg := g.newCluster("cluster")
+	_ = bg.Set("label", escapeLabel(g.clusterLabel("Broker", broker.Name, dns)))
 	g.subgraphs[key] = bg
-	bg.AddNode(bn)
-	g.AddSubgraph(bg)
+	g.addNodeTo(bg, bn)
+	g.addCluster(g.partOfGroup(broker.ObjectMeta), bg)
+
+	g.addDeadLetter(bn, broker.Namespace, broker.Spec.Delivery)
+	g.resolveDeadLetters(key, dns)
+}
+
+func (g *Graph) AddEventType(et eventingv1beta1.EventType) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addEventType(et)
+}
+
+func (g *Graph) addEventType(et eventingv1beta1.EventType) {
+	if g.full() {
+		return
+	}
+
+	broker := et.Spec.Broker
+	bk := g.brokerKey(et.Namespace, broker)
+	bn, ok := g.nodes[bk]
+	if !ok {
+		if bn = g.getOrCreateUnknown("Broker", bk, broker); bn == nil {
+			return
+		}
+		g.info[bk] = nodeInfo{kind: "Broker", namespace: et.Namespace, name: broker}
+	}
+	if g.full() {
+		// The placeholder broker took the last node.
+		return
+	}
+
+	g.recordEventTypes(bk, et.Spec.Type)
+
+	label := et.Spec.Type
+	if et.Spec.Schema != nil {
+		label = fmt.Sprintf("%s\n%s", label, et.Spec.Schema.String())
+	}
+
+	en := g.newNode(et.Namespace, "EventType "+et.Name)
+	_ = en.Set("shape", "note")
+	_ = en.Set("fontsize", "10")
+	_ = en.Set("label", escapeLabel(label))
+	_ = en.Set("URL", knative.ToYamlViewURL(et.Name, et.Kind, et.APIVersion))
+	g.setNodeColorForStatus(en, et.Status.Status)
+	g.setNodeForMeta(en, et.ObjectMeta)
+
+	if sg, ok := g.subgraphs[bk]; ok {
+		g.addNodeTo(sg, en)
+	} else {
+		g.addNodeTo(g.partOfGroup(et.ObjectMeta), en)
+	}
+	g.track(g.eventTypeKey(et.Namespace, et.Name), en)
+	g.info[g.eventTypeKey(et.Namespace, et.Name)] = objectInfo(et.Kind, et.APIVersion, et.ObjectMeta)
+
+	e := dot.NewEdge(en, bn)
+	_ = e.Set("dir", "none")
+	_ = e.Set("style", "dashed")
+	g.setEdgeColorForStatus(e, et.Status.Status)
+	g.addEdge(e, relEventType)
 }
 
 func (g *Graph) AddSource(source duckv1.Source) {
-	key := gvkKey(source.GroupVersionKind(), source.Name)
-	sn := dot.NewNode(fmt.Sprintf("Source %s\n%s\n%s", source.Name, source.Kind, source.GroupVersionKind().Group))
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addSource(source)
+}
+
+// AddPingSource adds a PingSource or CronJobSource like AddSource, drawn as
+// a clock face with its schedule and data in the label. Their types are not
+// available here and sources are listed as duck Sources, so the schedule and
+// data are passed in as read from the spec.
+func (g *Graph) AddPingSource(source duckv1.Source, schedule, data string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	lines := []string{"schedule: " + schedule}
+	if data != "" {
+		lines = append(lines, "data: "+data)
+	}
+	g.addDetailedSource(source, "Mcircle", lines)
+}
+
+// AddApiServerSource adds an ApiServerSource like AddSource, with a line in
+// the label for each kind of resource it watches and each event type it
+// sends. As for AddPingSource, the watched resources are passed in as read
+// from the spec.
+func (g *Graph) AddApiServerSource(source duckv1.Source, resources []schema.GroupVersionKind) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var lines []string
+	for _, gvk := range resources {
+		lines = append(lines, fmt.Sprintf("watches: %s", gvk.GroupKind()))
+	}
+	for _, ce := range source.Status.CloudEventAttributes {
+		lines = append(lines, "sends: "+ce.Type)
+	}
+	g.addDetailedSource(source, "cds", lines)
+}
+
+// AddSinkBinding adds a SinkBinding like AddSource, with a dashed edge to the
+// subject it injects its sink into. Subjects picked by a selector rather than
+// by name are drawn as one node for the selector. As for AddPingSource, the
+// subject is passed in as read from the spec.
+func (g *Graph) AddSinkBinding(binding duckv1.Source, subject tracker.Reference) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	bn := g.addSource(binding)
+	if bn == nil {
+		return
+	}
+	_ = bn.Set("shape", "component")
+
+	ns := subject.Namespace
+	if ns == "" {
+		ns = binding.Namespace
+	}
+	var sn *dot.Node
+	if subject.Name != "" || subject.Selector == nil {
+		sn = g.getOrCreateSubscriber(ns, &duckv1.Destination{Ref: &duckv1.KReference{
+			Kind:       subject.Kind,
+			Namespace:  ns,
+			Name:       subject.Name,
+			APIVersion: subject.APIVersion,
+		}})
+	} else {
+		selector := metav1.FormatLabelSelector(subject.Selector)
+		gv, _ := schema.ParseGroupVersion(subject.APIVersion)
+		sn = g.getOrCreateUnknown("Subject", g.resourceKey(gv.Group, subject.Kind, ns, selector),
+			fmt.Sprintf("%s %s", subject.Kind, selector))
+	}
+	if sn == nil {
+		return
+	}
+
+	e := dot.NewEdge(bn, sn)
+	_ = e.Set("style", "dashed")
+	_ = e.Set("label", "injects")
+	g.setEdgeColorForStatus(e, binding.Status.Status)
+	g.addEdge(e, relSubject)
+}
+
+// addDetailedSource adds source drawn with shape and the lines of detail
+// appended to its label.
+func (g *Graph) addDetailedSource(source duckv1.Source, shape string, lines []string) {
+	sn := g.addSource(source)
+	if sn == nil {
+		return
+	}
+	_ = sn.Set("shape", shape)
+	label := sn.Get("label")
+	if label == "" {
+		label = sn.Name()
+	}
+	for _, line := range lines {
+		label += "\n" + escapeLabel(line)
+	}
+	_ = sn.Set("label", label)
+}
+
+// addSource adds source and the edge to its sink, returning its node, or nil
+// if the graph is full.
+func (g *Graph) addSource(source duckv1.Source) *dot.Node {
+	if g.full() {
+		return nil
+	}
+
+	key := g.gvkKey(source.GroupVersionKind(), source.Namespace, source.Name)
+	sn := g.newNode(source.Namespace, fmt.Sprintf("Source %s\n%s\n%s", source.Name, source.Kind, source.GroupVersionKind().Group))
 	_ = sn.Set("shape", "box")
+	if g.compactSourceLabels {
+		_ = sn.Set("label", fmt.Sprintf("%s (%s)", source.Name, source.Kind))
+	}
 
-	setNodeColorForStatus(sn, source.Status.Status)
+	g.setNodeColorForStatus(sn, source.Status.Status)
+	g.setNodeForMeta(sn, source.ObjectMeta)
+	if g.highlightSources {
+		label := sn.Get("label")
+		if label == "" {
+			label = sn.Name()
+		}
+		_ = sn.Set("label", "▶ "+label)
+		_ = sn.Set("style", sn.Get("style")+",bold")
+	}
 	_ = sn.Set("URL", knative.ToYamlViewURL(source.Name, source.Kind, source.APIVersion))
-	g.AddNode(sn)
-	g.nodes[key] = sn
-
-	fmt.Println("source ", source.Name, sn.String())
 
 	sink := sinkDNS(source)
+	if sink == "" && source.Spec.Sink.Ref != nil {
+		sink = g.refSinkDNS(source.Namespace, source.Spec.Sink.Ref)
+	}
+
+	if g.groupSourcesBySink && sink != "" {
+		g.addNodeTo(g.sourceGroup(sink), sn)
+	} else {
+		g.addNodeTo(g.partOfGroup(source.ObjectMeta), sn)
+	}
+	g.track(key, sn)
+	g.info[key] = objectInfo(source.Kind, source.APIVersion, source.ObjectMeta)
 
 	if sink != "" {
-		var bn *dot.Node
-		var bk string
-		var ok bool
-		if bk, ok = g.dnsToKey[sink]; !ok {
-			// TODO: unknown sink.
-			bn = dot.NewNode("UnknownSink " + sink)
-			g.AddNode(bn)
-		} else {
-			if bn, ok = g.nodes[bk]; !ok {
-				// TODO: unknown broker.
-				bn = dot.NewNode("UnknownSink " + sink)
-				g.AddNode(bn)
+		for _, ce := range source.Status.CloudEventAttributes {
+			g.recordEventTypes(g.dnsToKey[sink], ce.Type)
+		}
+		if bn := g.getOrCreateSink(sink); bn != nil {
+			e := dot.NewEdge(sn, bn)
+			g.setEdgeColorForStatus(e, source.Status.Status)
+			if g.tooltips {
+				_ = e.Set("tooltip", flowTooltip(source.Name, g.sinkName(sink)))
+			}
+			g.clipToClusters(e)
+			g.addEdge(e, relSink)
+		}
+	}
+	return sn
+}
+
+// refSinkDNS returns the address the sink ref of a source in ns resolves to,
+// for sources whose status has no sink URI yet. A ref without a namespace is
+// in ns. The address is the one registered for the resource the ref names,
+// or else the address of the in-cluster service expected for it. It returns
+// "" if neither is in the graph.
+func (g *Graph) refSinkDNS(ns string, ref *duckv1.KReference) string {
+	if ref.Namespace != "" {
+		ns = ref.Namespace
+	}
+	if dns := g.registeredDNS(g.destinationKey(ns, &duckv1.Destination{Ref: ref})); dns != "" {
+		return dns
+	}
+
+	expected := []string{fmt.Sprintf("http://%s.%s.svc.cluster.local", ref.Name, ns)}
+	if ref.Kind == "Broker" {
+		expected = append(expected,
+			fmt.Sprintf("http://broker-ingress.knative-eventing.svc.cluster.local/%s/%s", ns, ref.Name),
+			fmt.Sprintf("http://%s-broker.%s.svc.cluster.local", ref.Name, ns),
+		)
+	}
+	for _, dns := range expected {
+		if _, ok := g.dnsToKey[dns]; ok {
+			return dns
+		}
+	}
+	return ""
+}
+
+// registeredDNS returns the first address registered for the node with key,
+// or "" if there is none.
+func (g *Graph) registeredDNS(key string) string {
+	var registered []string
+	for dns, k := range g.dnsToKey {
+		if k == key {
+			registered = append(registered, dns)
+		}
+	}
+	if len(registered) == 0 {
+		return ""
+	}
+	sort.Strings(registered)
+	return registered[0]
+}
+
+// sinkEnvDNS returns the address the value of a sink env var resolves to.
+// Values often name the in-cluster service of a broker or channel rather
+// than the address in its status, so addresses are matched ignoring scheme
+// and port, then ignoring the path of the value, and a
+// "name.namespace.svc.cluster.local" host is matched to a broker or channel
+// by name. The value is returned as is if nothing matches.
+func (g *Graph) sinkEnvDNS(value string) string {
+	dns := strings.TrimSuffix(value, "/")
+	if _, ok := g.dnsToKey[dns]; ok {
+		return dns
+	}
+	host, path, ok := hostAndPath(dns)
+	if !ok {
+		return dns
+	}
+
+	byAddress := make(map[string]string, len(g.dnsToKey))
+	for registered := range g.dnsToKey {
+		h, p, ok := hostAndPath(registered)
+		if !ok {
+			continue
+		}
+		if prev, ok := byAddress[h+p]; !ok || registered < prev {
+			byAddress[h+p] = registered
+		}
+	}
+	for _, address := range []string{host + path, host} {
+		if registered, ok := byAddress[address]; ok {
+			return registered
+		}
+	}
+
+	if parts := strings.Split(host, "."); len(parts) > 2 && parts[2] == "svc" {
+		name, ns := parts[0], parts[1]
+		for _, key := range []string{
+			g.brokerKey(ns, name),
+			g.brokerKey(ns, strings.TrimSuffix(name, "-broker")),
+			g.channelKey(ns, name),
+			g.inMemoryChannelKey(ns, name),
+		} {
+			if registered := g.registeredDNS(key); registered != "" {
+				return registered
 			}
 		}
+	}
+	return dns
+}
 
-		e := dot.NewEdge(sn, bn)
-		setEdgeColorForStatus(e, source.Status.Status)
-		if sg, ok := g.subgraphs[bk]; ok {
-			// This is not working.
-			_ = e.Set("lhead", sg.Name())
+// hostAndPath splits an address, with or without a scheme, into its host
+// without the port and its path without a trailing slash.
+func hostAndPath(address string) (string, string, bool) {
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	u, err := url.Parse(address)
+	if err != nil || u.Hostname() == "" {
+		return "", "", false
+	}
+	return u.Hostname(), strings.TrimSuffix(u.Path, "/"), true
+}
+
+// sourceGroup returns the cluster holding the sources that sink to dns.
+func (g *Graph) sourceGroup(dns string) *dot.SubGraph {
+	if sg, ok := g.sourceGroups[dns]; ok {
+		return sg
+	}
+	sg := g.newCluster("cluster_sources")
+	_ = sg.Set("label", "Sources for "+dns)
+	// dot only accepts graph attributes on subgraphs, so no border style.
+	_ = sg.Set("bgcolor", "whitesmoke")
+	g.sourceGroups[dns] = sg
+	g.addCluster(nil, sg)
+	return sg
+}
+
+// partOfGroup returns the cluster for the application meta is part of, or
+// else for its namespace, or nil to place it at the root.
+func (g *Graph) partOfGroup(meta metav1.ObjectMeta) *dot.SubGraph {
+	app := meta.Labels[PartOfLabel]
+	if !g.partOfGrouping || app == "" {
+		return g.namespaceGroup(meta.Namespace)
+	}
+	gk := app
+	if g.namespaceClusters && meta.Namespace != "" {
+		gk = meta.Namespace + "/" + app
+	}
+	if sg, ok := g.partOfGroups[gk]; ok {
+		return sg
+	}
+	sg := g.newCluster("cluster_app")
+	_ = sg.Set("label", app)
+	g.partOfGroups[gk] = sg
+	g.addCluster(g.namespaceGroup(meta.Namespace), sg)
+	return sg
+}
+
+// namespaceGroup returns the cluster for the namespace ns, or nil to place
+// its resources at the root. Once a second namespace is drawn, the default
+// title counts the namespaces instead.
+func (g *Graph) namespaceGroup(ns string) *dot.SubGraph {
+	if !g.namespaceClusters || ns == "" {
+		return nil
+	}
+	if sg, ok := g.namespaceGroups[ns]; ok {
+		return sg
+	}
+	sg := dot.NewSubgraph("cluster_ns_" + ns)
+	_ = sg.Set("label", "Namespace "+ns)
+	g.namespaceGroups[ns] = sg
+	g.addCluster(nil, sg)
+
+	if n := len(g.namespaceGroups); n > 1 {
+		// Counts a render appended to the title are kept after it.
+		label := strings.TrimSuffix(g.Get("label"), g.titleSuffix)
+		if label == "Triggers in "+g.ns || label == fmt.Sprintf("Triggers across %d namespaces", n-1) {
+			_ = g.Set("label", fmt.Sprintf("Triggers across %d namespaces", n)+g.titleSuffix)
 		}
-		g.AddEdge(e)
 	}
+	return sg
+}
+
+// triggerGroup returns the cluster holding the triggers of the broker with
+// key bk that deliver to subscriber. It is nested in the broker cluster.
+func (g *Graph) triggerGroup(bk, ns string, subscriber *duckv1.Destination) *dot.SubGraph {
+	gk := bk + "|" + g.destinationKey(ns, subscriber)
+	if sg, ok := g.triggerGroups[gk]; ok {
+		return sg
+	}
+	sg := g.newCluster("cluster_triggers")
+	_ = sg.Set("label", "to "+destinationName(subscriber))
+	g.triggerGroups[gk] = sg
+	g.addCluster(g.subgraphs[bk], sg)
+	return sg
 }
 
 func (g *Graph) AddTrigger(trigger eventingv1beta1.Trigger) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addTrigger(trigger)
+}
+
+func (g *Graph) addTrigger(trigger eventingv1beta1.Trigger) {
+	if g.full() {
+		return
+	}
+
 	broker := trigger.Spec.Broker
-	bk := brokerKey(broker)
+	bk := g.brokerKey(trigger.Namespace, broker)
 	bn, ok := g.nodes[bk]
 	if !ok {
-		bn = dot.NewNode("UnknownBroker " + broker)
-		g.AddNode(bn)
-		g.nodes[bk] = bn
+		if bn = g.getOrCreateUnknown("Broker", bk, broker); bn == nil {
+			return
+		}
+		g.info[bk] = nodeInfo{kind: "Broker", namespace: trigger.Namespace, name: broker}
+	}
+
+	if g.triggerTable {
+		if _, ok := g.nodes[triggerTableKey(bk)]; !ok && g.full() {
+			return
+		}
+		g.recordFilter(bk, trigger)
+		g.addTriggerRow(bk, bn, trigger)
+		return
+	}
+	if g.full() {
+		// The placeholder broker took the last node.
+		return
 	}
+	g.recordFilter(bk, trigger)
 
-	tn := dot.NewNode("Trigger " + trigger.Name)
+	tn := g.newNode(trigger.Namespace, "Trigger "+trigger.Name)
 	_ = tn.Set("shape", "box")
 	_ = tn.Set("URL", knative.ToYamlViewURL(trigger.Name, trigger.Kind, trigger.APIVersion))
-	setNodeColorForStatus(tn, trigger.Status.Status)
+	g.setNodeColorForStatus(tn, trigger.Status.Status)
+	g.setNodeForMeta(tn, trigger.ObjectMeta)
 
-	if sg, ok := g.subgraphs[bk]; ok {
-		sg.AddNode(tn)
+	if g.groupTriggersBySubscriber {
+		g.addNodeTo(g.triggerGroup(bk, trigger.Namespace, &trigger.Spec.Subscriber), tn)
+	} else if sg, ok := g.subgraphs[bk]; ok {
+		g.addNodeTo(sg, tn)
 	} else {
-		g.AddNode(tn)
+		g.addNodeTo(g.partOfGroup(trigger.ObjectMeta), tn)
 	}
-	g.nodes[triggerKey(trigger.Name)] = tn
+	g.track(g.triggerKey(trigger.Namespace, trigger.Name), tn)
+	g.info[g.triggerKey(trigger.Namespace, trigger.Name)] = objectInfo(trigger.Kind, trigger.APIVersion, trigger.ObjectMeta)
 
-	if trigger.Spec.Filter != nil && trigger.Spec.Filter.Attributes != nil {
+	be := dot.NewEdge(bn, tn)
+	g.setEdgeColorForStatus(be, trigger.Status.Status)
+	if g.ingressPorts {
+		_ = be.Set("tailport", g.nextPort(bk))
+	}
+	g.addEdge(be, relTrigger)
+
+	// v1beta1 triggers only filter on attributes, one line each, sorted so
+	// the label does not change between renders.
+	if trigger.Spec.Filter != nil && len(trigger.Spec.Filter.Attributes) > 0 {
+		attrs := make([]string, 0, len(trigger.Spec.Filter.Attributes))
+		for k := range trigger.Spec.Filter.Attributes {
+			attrs = append(attrs, k)
+		}
+		sort.Strings(attrs)
 		filter := ""
-		for k, v := range trigger.Spec.Filter.Attributes {
-			filter = fmt.Sprintf("%s\n%s=%s", filter, k, v)
+		for _, k := range attrs {
+			filter = fmt.Sprintf("%s\n%s: %s", filter, k, trigger.Spec.Filter.Attributes[k])
 		}
-		_ = tn.Set("label", fmt.Sprintf("%s%s", tn.Name(), filter))
+		_ = tn.Set("label", escapeLabel(fmt.Sprintf("Trigger %s%s", trigger.Name, filter)))
 	}
 
-	if sub := g.getOrCreateSubscriber(&trigger.Spec.Subscriber); sub != nil {
+	if sub := g.getOrCreateSubscriber(trigger.Namespace, &trigger.Spec.Subscriber); sub != nil {
 		e := dot.NewEdge(tn, sub)
 		_ = e.Set("dir", "both")
-		setEdgeColorForStatus(e, trigger.Status.Status)
-		fmt.Println("sub", sub, e)
-		g.AddEdge(e)
+		g.setEdgeColorForStatus(e, trigger.Status.Status)
+		if g.latencyLabels {
+			if latency, ok := trigger.Annotations[LatencyAnnotation]; ok {
+				appendEdgeLabel(e, latency)
+			}
+		}
+		if g.concurrencyLabels {
+			if concurrency, ok := trigger.Annotations[ConcurrencyAnnotation]; ok {
+				appendEdgeLabel(e, "concurrency: "+concurrency)
+			}
+		}
+		g.setEdgeDelivery(e, g.brokerDelivery[bk])
+		g.setResolvedURL(e, &trigger.Spec.Subscriber, trigger.Status.SubscriberURI)
+		g.setAuthInfo(e, trigger.ObjectMeta)
+		// Triggers delivering to a channel chain onto that channel's cluster.
+		g.clipToClusters(e)
+		g.addEdge(e, relSubscriber)
 	}
+
+	// eventing v1beta1 Triggers have no delivery of their own, their
+	// broker's dead letter sink is drawn from the broker.
 }
 
 func (g *Graph) LoadKnService(service servingv1.Service) {
-	key := servingKey(service.Kind, service.Name)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.full() {
+		return
+	}
+	defaultServiceType(&service)
+
+	key := g.servingKey(service.Kind, service.Namespace, service.Name)
 
 	var svc *dot.Node
 	var ok bool
@@ -246,28 +1193,42 @@ func (g *Graph) LoadKnService(service servingv1.Service) {
 			service.Kind,
 			service.GroupVersionKind().Group,
 		)
-		svc = dot.NewNode(label)
+		svc = g.newNode(service.Namespace, label)
 
 		_ = svc.Set("URL", knative.ToYamlViewURL(service.Name, service.Kind, service.APIVersion))
 		setNodeShapeForKind(svc, service.Kind, service.APIVersion)
-		setNodeColorForStatus(svc, service.Status.Status)
+		g.setNodeColorForStatus(svc, service.Status.Status)
+		g.setNodeForMeta(svc, service.ObjectMeta)
 
 		//_ = svc.Set("shape", "septagon")
 
-		g.nodes[key] = svc
-		g.AddNode(svc)
+		g.track(key, svc)
+		g.addNodeTo(g.partOfGroup(service.ObjectMeta), svc)
 
 		if service.Status.Address != nil && service.Status.Address.URL != nil {
 			dns := service.Status.Address.URL.String()
 			g.dnsToKey[dns] = key
-			fmt.Println(key, "-->", dns)
 		}
 	}
+	// The node may be a placeholder drawn for a reference to the service,
+	// which knew less about it.
+	g.info[key] = objectInfo(service.Kind, service.APIVersion, service.ObjectMeta)
 }
 
 func (g *Graph) AddKnService(service servingv1.Service) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addKnService(service)
+}
+
+func (g *Graph) addKnService(service servingv1.Service) {
+	defaultServiceType(&service)
 	config := service.Spec.ConfigurationSpec
-	key := servingKey(service.Kind, service.Name)
+	key := g.servingKey(service.Kind, service.Namespace, service.Name)
+	if _, ok := g.nodes[key]; !ok && g.full() {
+		return
+	}
 
 	var svc *dot.Node
 	var ok bool
@@ -278,102 +1239,297 @@ func (g *Graph) AddKnService(service servingv1.Service) {
 			service.Kind,
 			service.GroupVersionKind().Group,
 		)
-		svc = dot.NewNode(label)
+		svc = g.newNode(service.Namespace, label)
 		_ = svc.Set("URL", knative.ToYamlViewURL(service.Name, service.Kind, service.APIVersion))
 		setNodeShapeForKind(svc, service.Kind, service.APIVersion)
-		setNodeColorForStatus(svc, service.Status.Status)
+		g.setNodeColorForStatus(svc, service.Status.Status)
+		g.setNodeForMeta(svc, service.ObjectMeta)
 
 		//_ = svc.Set("shape", "septagon")
 
-		g.nodes[key] = svc
-		g.AddNode(svc)
+		g.track(key, svc)
+		g.addNodeTo(g.partOfGroup(service.ObjectMeta), svc)
 	}
-
-	//	fmt.Println(service, "kn svc:", svc)
-
+	g.info[key] = objectInfo(service.Kind, service.APIVersion, service.ObjectMeta)
+	// Brokers and channels added before may dead letter into the service,
+	// by reference or by address.
+	dns := ""
+	if service.Status.Address != nil {
+		dns = strings.TrimSuffix(service.Status.Address.URL.String(), "/")
+	}
+	g.resolveDeadLetters(key, dns)
+
+	// K_SINK is injected with the resolved address of the sink, so it often
+	// repeats SINK or TARGET. Each sink is drawn once, in DNS order so the
+	// output does not depend on the order of the env.
+	if len(config.Template.Spec.Containers) == 0 {
+		g.malformed("%s has no containers", key)
+		return
+	}
+	var sinks []string
+	drawn := make(map[string]bool)
 	for _, env := range config.Template.Spec.Containers[0].Env {
-		switch env.Name {
-		case "SINK":
-			fallthrough
-		case "TARGET":
-			// Assume full dns name.
-			target := g.getOrCreateSink(env.Value)
-			e := dot.NewEdge(svc, target)
-			setEdgeColorForStatus(e, service.Status.Status)
-			g.AddEdge(e)
+		if !g.sinkEnvNames[env.Name] {
+			continue
+		}
+		dns := g.sinkEnvDNS(env.Value)
+		if drawn[dns] {
+			continue
 		}
+		drawn[dns] = true
+		sinks = append(sinks, dns)
+	}
+	sort.Strings(sinks)
+	if len(sinks) == 0 {
+		g.warn("%s has no sink env", key)
+	}
+
+	for _, dns := range sinks {
+		// Assume full dns name.
+		target := g.getOrCreateSink(dns)
+		if target == nil {
+			continue
+		}
+		e := dot.NewEdge(svc, target)
+		g.setEdgeColorForStatus(e, service.Status.Status)
+		if g.tooltips {
+			_ = e.Set("tooltip", flowTooltip(service.Name, g.sinkName(dns)))
+		}
+		g.clipToClusters(e)
+		g.addEdge(e, relSink)
+	}
+}
+
+// defaultServiceType fills in the kind and API version of services listed
+// by typed clients, which leave them empty, so they key and draw the same as
+// the services references point at.
+func defaultServiceType(service *servingv1.Service) {
+	if service.Kind == "" {
+		service.Kind = "Service"
+	}
+	if service.APIVersion == "" {
+		service.APIVersion = servingv1.SchemeGroupVersion.String()
 	}
 }
 
 func (g *Graph) AddSequence(seq flowsv1beta1.Sequence) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 
-	key := sequenceKey(seq.Name)
+	g.addSequence(seq)
+}
 
-	uri := seq.Status.Address.URL
-	dns := strings.TrimSuffix(uri.String(), "/")
+func (g *Graph) addSequence(seq flowsv1beta1.Sequence) {
+	if g.full() {
+		return
+	}
+
+	key := g.sequenceKey(seq.Namespace, seq.Name)
 
-	sg := dot.NewSubgraph(fmt.Sprintf("cluster_%d", len(g.subgraphs)))
-	_ = sg.Set("label", fmt.Sprintf("Sequence %s\n%s", seq.Name, dns))
+	dns := ""
+	if seq.Status.Address != nil {
+		dns = strings.TrimSuffix(seq.Status.Address.URL.String(), "/")
+	}
+
+	sg := g.newCluster("cluster")
+	_ = sg.Set("label", escapeLabel(g.clusterLabel("Sequence", seq.Name, dns)))
 	//	_ = sg.Set("rankdir", "BT")
 
-	g.dnsToKey[dns] = key
-	sn := dot.NewNode("Sequence " + dns)
+	name := dns
+	if dns != "" {
+		g.dnsToKey[dns] = key
+	} else {
+		name = key
+	}
+	sn := dot.NewNode("Sequence " + name)
 	_ = sn.Set("label", "Start")
 	_ = sn.Set("URL", knative.ToYamlViewURL(seq.Name, seq.Kind, seq.APIVersion))
-	setNodeColorForStatus(sn, seq.Status.Status)
+	g.setNodeColorForStatus(sn, seq.Status.Status)
+	g.setNodeForMeta(sn, seq.ObjectMeta)
 
-	g.nodes[key] = sn
-	sg.AddNode(sn)
+	g.track(key, sn)
+	g.info[key] = objectInfo(seq.Kind, seq.APIVersion, seq.ObjectMeta)
+	g.addNodeTo(sg, sn)
+	g.sequenceSteps[key] = []string{sn.Name()}
 
 	previousNode := sn
 
 	for num, step := range seq.Spec.Steps {
-		stepKey := sequenceStepKey(seq.Name, num)
+		if g.full() {
+			break
+		}
+		stepKey := g.sequenceStepKey(seq.Namespace, seq.Name, num)
 		stepn := dot.NewNode(stepKey)
 		_ = stepn.Set("label", fmt.Sprintf("Step %d", num))
 		_ = stepn.Set("shape", "box")
 
 		// Add to seq subgraph.
-		sg.AddNode(stepn)
+		g.addNodeTo(sg, stepn)
+		g.sequenceSteps[key] = append(g.sequenceSteps[key], stepn.Name())
 
-		g.nodes[stepKey] = stepn
+		g.track(stepKey, stepn)
+		g.parts[key] = append(g.parts[key], stepKey)
 
-		if sub := g.getOrCreateSubscriber(&step.Destination); sub != nil {
+		if sub := g.getOrCreateSubscriber(seq.Namespace, &step.Destination); sub != nil {
 			e := dot.NewEdge(stepn, sub)
 			_ = e.Set("dir", "both")
-			setEdgeColorForStatus(e, seq.Status.Status)
-			g.AddEdge(e)
+			g.setEdgeColorForStatus(e, seq.Status.Status)
+			g.addEdge(e, relSubscriber)
 		}
 
 		e := dot.NewEdge(previousNode, stepn)
-		setEdgeColorForStatus(e, seq.Status.Status)
-		g.AddEdge(e)
+		g.setEdgeColorForStatus(e, seq.Status.Status)
+		g.addEdge(e, relStep)
 		previousNode = stepn
 	}
 
 	if seq.Spec.Reply != nil {
-		replyn := dot.NewNode("Reply " + dns)
+		replyn := dot.NewNode("Reply " + name)
 		_ = replyn.Set("label", "Reply")
 		//_ = replyn.Set("rank", "max")
-		//g.nodes[] = rn
-		sg.AddNode(replyn)
+		rk := g.sequenceReplyKey(seq.Namespace, seq.Name)
+		g.track(rk, replyn)
+		g.parts[key] = append(g.parts[key], rk)
+		g.addNodeTo(sg, replyn)
+		g.sequenceSteps[key] = append(g.sequenceSteps[key], replyn.Name())
 
 		// TODO where this points.
 		e := dot.NewEdge(previousNode, replyn)
-		setEdgeColorForStatus(e, seq.Status.Status)
-		g.AddEdge(e)
+		g.setEdgeColorForStatus(e, seq.Status.Status)
+		g.addEdge(e, relReply)
+
+		if rn, ok := g.nodes[g.destinationKey(seq.Namespace, seq.Spec.Reply)]; ok {
+			e := dot.NewEdge(replyn, rn)
+			g.setEdgeColorForStatus(e, seq.Status.Status)
+			g.addEdge(e, relReply)
+		}
+	}
+
+	g.subgraphs[key] = sg
+	g.addCluster(g.partOfGroup(seq.ObjectMeta), sg)
+
+}
+
+func (g *Graph) AddParallel(p flowsv1beta1.Parallel) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addParallel(p)
+}
+
+func (g *Graph) addParallel(p flowsv1beta1.Parallel) {
+	if g.full() {
+		return
+	}
+
+	key := g.parallelKey(p.Namespace, p.Name)
+
+	dns := ""
+	if p.Status.Address != nil {
+		dns = strings.TrimSuffix(p.Status.Address.URL.String(), "/")
+	}
+
+	sg := g.newCluster("cluster")
+	_ = sg.Set("label", escapeLabel(g.clusterLabel("Parallel", p.Name, dns)))
+
+	if dns != "" {
+		g.dnsToKey[dns] = key
+	}
+	sn := g.newNode(p.Namespace, "Parallel "+p.Name)
+	_ = sn.Set("label", "Start")
+	_ = sn.Set("URL", knative.ToYamlViewURL(p.Name, p.Kind, p.APIVersion))
+	g.setNodeColorForStatus(sn, p.Status.Status)
+	g.setNodeForMeta(sn, p.ObjectMeta)
+
+	g.track(key, sn)
+	g.info[key] = objectInfo(p.Kind, p.APIVersion, p.ObjectMeta)
+	g.addNodeTo(sg, sn)
+
+	var replyn *dot.Node
+	if p.Spec.Reply != nil {
+		replyn = dot.NewNode("Reply " + key)
+		_ = replyn.Set("label", "Reply")
+		rk := g.parallelReplyKey(p.Namespace, p.Name)
+		g.track(rk, replyn)
+		g.parts[key] = append(g.parts[key], rk)
+		g.addNodeTo(sg, replyn)
+	}
+
+	for num, branch := range p.Spec.Branches {
+		if g.full() {
+			break
+		}
+		branchKey := g.parallelBranchKey(p.Namespace, p.Name, num)
+		branchn := dot.NewNode(branchKey)
+		_ = branchn.Set("label", fmt.Sprintf("Branch %d", num))
+		_ = branchn.Set("shape", "box")
+
+		// Add to parallel subgraph.
+		g.addNodeTo(sg, branchn)
+
+		g.track(branchKey, branchn)
+		g.parts[key] = append(g.parts[key], branchKey)
 
-		rk := destinationKey(seq.Spec.Reply)
-		if rn, ok := g.nodes[rk]; ok {
+		e := dot.NewEdge(sn, branchn)
+		g.setEdgeColorForStatus(e, p.Status.Status)
+		g.addEdge(e, relStep)
+
+		if sub := g.getOrCreateSubscriber(p.Namespace, &branch.Subscriber); sub != nil {
+			e := dot.NewEdge(branchn, sub)
+			_ = e.Set("dir", "both")
+			g.setEdgeColorForStatus(e, p.Status.Status)
+			g.setEdgeDelivery(e, branch.Delivery)
+			g.addEdge(e, relSubscriber)
+		}
+
+		// Branches without their own reply reply to the Parallel reply.
+		if branch.Reply != nil {
+			if rn := g.getOrCreateReply(p.Namespace, branch.Reply); rn != nil {
+				e := dot.NewEdge(branchn, rn)
+				g.setEdgeColorForStatus(e, p.Status.Status)
+				g.addEdge(e, relReply)
+			}
+		} else if replyn != nil {
+			e := dot.NewEdge(branchn, replyn)
+			g.setEdgeColorForStatus(e, p.Status.Status)
+			g.addEdge(e, relReply)
+		}
+	}
+
+	if replyn != nil {
+		if rn := g.getOrCreateReply(p.Namespace, p.Spec.Reply); rn != nil {
 			e := dot.NewEdge(replyn, rn)
-			setEdgeColorForStatus(e, seq.Status.Status)
-			g.AddEdge(e)
+			g.setEdgeColorForStatus(e, p.Status.Status)
+			g.addEdge(e, relReply)
 		}
 	}
 
 	g.subgraphs[key] = sg
-	g.AddSubgraph(sg)
+	g.addCluster(g.partOfGroup(p.ObjectMeta), sg)
+}
+
+func defaultClusterLabel(kind, name, dns string) string {
+	return fmt.Sprintf("%s %s\n%s", kind, name, dns)
+}
 
+// BrokerClassAnnotation is the Broker annotation naming its class.
+const BrokerClassAnnotation = "eventing.knative.dev/broker.class"
+
+// BrokerClassShapes maps a broker class to the shape its brokers are drawn
+// with. Brokers of other classes are drawn as ovals. Add to it to draw more
+// classes distinctly.
+var BrokerClassShapes = map[string]string{
+	"ChannelBasedBroker":   "oval",
+	"MTChannelBasedBroker": "oval",
+	"Kafka":                "cylinder",
+	"RabbitMQBroker":       "hexagon",
+}
+
+func brokerShape(class string) string {
+	if shape, ok := BrokerClassShapes[class]; ok {
+		return shape
+	}
+	return "oval"
 }
 
 func setNodeShapeForKind(node *dot.Node, kind, apiVersion string) {
@@ -385,7 +1541,19 @@ func setNodeShapeForKind(node *dot.Node, kind, apiVersion string) {
 	}
 }
 
-func getColorMapForStatus(status duckv1.Status) map[string]string {
+// readinessPalette colors resources and the edges into them by their Ready
+// condition. Resources without one are purple.
+type readinessPalette struct {
+	ready, notReady, unknown string
+}
+
+var defaultReadinessPalette = readinessPalette{
+	ready:    "black",
+	notReady: "deeppink",
+	unknown:  "darkorange2",
+}
+
+func getColorMapForStatus(status duckv1.Status, palette readinessPalette) map[string]string {
 	cond := status.GetCondition(apis.ConditionReady)
 	attrs := make(map[string]string)
 	if cond == nil {
@@ -393,21 +1561,30 @@ func getColorMapForStatus(status duckv1.Status) map[string]string {
 	}
 	if cond == nil {
 		attrs["color"] = "purple"
-		attrs["tootlip"] = "missing status field"
+		attrs["tooltip"] = "missing status field"
 	} else if cond.IsTrue() {
-		attrs["color"] = "black"
+		attrs["color"] = palette.ready
 		attrs["tooltip"] = fmt.Sprintf("Ready as of %s", cond.LastTransitionTime.Inner.String())
 	} else if cond.IsUnknown() {
-		attrs["color"] = "darkorange2"
-		attrs["tooltip"] = fmt.Sprintf("[%s] %s: %s", cond.Status, cond.Reason, cond.Message)
+		attrs["color"] = palette.unknown
+		attrs["tooltip"] = conditionTooltip(cond)
 	} else if cond.IsFalse() {
-		attrs["color"] = "deeppink"
-		attrs["tooltip"] = fmt.Sprintf("[%s] %s: %s", cond.Status, cond.Reason, cond.Message)
+		attrs["color"] = palette.notReady
+		attrs["tooltip"] = conditionTooltip(cond)
 	}
 	return attrs
 }
 
-func getColorMapForStatusV1Beta1(status duckv1beta1.Status) map[string]string {
+// conditionTooltip describes a condition that is not true by its reason and
+// message.
+func conditionTooltip(cond *apis.Condition) string {
+	if cond.Message == "" {
+		return fmt.Sprintf("[%s] %s", cond.Status, cond.Reason)
+	}
+	return fmt.Sprintf("[%s] %s: %s", cond.Status, cond.Reason, cond.Message)
+}
+
+func getColorMapForStatusV1Beta1(status duckv1beta1.Status, palette readinessPalette) map[string]string {
 	cond := status.GetCondition(apis.ConditionReady)
 	if cond == nil {
 		cond = status.GetCondition(apis.ConditionSucceeded)
@@ -415,56 +1592,138 @@ func getColorMapForStatusV1Beta1(status duckv1beta1.Status) map[string]string {
 	attrs := make(map[string]string)
 	if cond == nil {
 		attrs["color"] = "purple"
-		attrs["tootlip"] = "missing status field"
+		attrs["tooltip"] = "missing status field"
 	} else if cond.IsTrue() {
-		attrs["color"] = "black"
+		attrs["color"] = palette.ready
 		attrs["tooltip"] = fmt.Sprintf("Ready as of %s", cond.LastTransitionTime.Inner.String())
 	} else if cond.IsUnknown() {
-		attrs["color"] = "darkorange2"
-		attrs["tooltip"] = fmt.Sprintf("[%s] %s: %s", cond.Status, cond.Reason, cond.Message)
+		attrs["color"] = palette.unknown
+		attrs["tooltip"] = conditionTooltip(cond)
 	} else if cond.IsFalse() {
-		attrs["color"] = "deeppink"
-		attrs["tooltip"] = fmt.Sprintf("[%s] %s: %s", cond.Status, cond.Reason, cond.Message)
+		attrs["color"] = palette.notReady
+		attrs["tooltip"] = conditionTooltip(cond)
 	}
 	return attrs
 }
 
-func setNodeColorForStatus(node *dot.Node, status duckv1.Status) {
+func (g *Graph) setNodeColorForStatus(node *dot.Node, status duckv1.Status) {
 	_ = node.Set("fillcolor", "white")
 	_ = node.Set("style", "filled")
-	for name, value := range getColorMapForStatus(status) {
+	if !g.readinessColors {
+		return
+	}
+	for name, value := range getColorMapForStatus(status, g.readinessPalette) {
 		_ = node.Set(name, value)
 	}
+	if cond := status.GetCondition(apis.ConditionReady); cond != nil && !cond.IsTrue() {
+		g.notReady[node] = true
+	}
 }
 
-func setEdgeColorForStatus(edge *dot.Edge, status duckv1.Status) {
-	for name, value := range getColorMapForStatus(status) {
+// setNodeForMeta styles the node from the metadata of the resource it was
+// drawn for, as enabled by the graph options.
+func (g *Graph) setNodeForMeta(node *dot.Node, meta metav1.ObjectMeta) {
+	if g.deletionState && meta.DeletionTimestamp != nil {
+		_ = node.Set("color", "red")
+		_ = node.Set("style", "filled,dashed")
+		_ = node.Set("tooltip", fmt.Sprintf("Terminating since %s", meta.DeletionTimestamp.String()))
+	}
+	for _, key := range g.annotationTooltips {
+		if value, ok := meta.Annotations[key]; ok {
+			appendNodeTooltip(node, fmt.Sprintf("%s: %s", key, value))
+		}
+	}
+}
+
+func appendNodeTooltip(node *dot.Node, text string) {
+	if tooltip := node.Get("tooltip"); tooltip != "" {
+		text = tooltip + "\n" + text
+	}
+	_ = node.Set("tooltip", text)
+}
+
+func (g *Graph) setEdgeColorForStatus(edge *dot.Edge, status duckv1.Status) {
+	if !g.readinessColors {
+		return
+	}
+	for name, value := range getColorMapForStatus(status, g.readinessPalette) {
 		_ = edge.Set(name, value)
 	}
 }
 
+func appendEdgeTooltip(edge *dot.Edge, text string) {
+	if tooltip := edge.Get("tooltip"); tooltip != "" {
+		text = tooltip + "\n" + text
+	}
+	_ = edge.Set("tooltip", text)
+}
+
+// setAuthInfo adds the audience deliveries to the subscriber are
+// authenticated with to the tooltip of the subscriber edge.
+func (g *Graph) setAuthInfo(edge *dot.Edge, meta metav1.ObjectMeta) {
+	if !g.authInfo {
+		return
+	}
+	// TODO: read Destination.Audience once the vendored eventing has it.
+	if audience, ok := meta.Annotations[AudienceAnnotation]; ok {
+		appendEdgeTooltip(edge, "audience: "+audience)
+	}
+}
+
+func appendEdgeLabel(edge *dot.Edge, text string) {
+	if label := edge.Get("label"); label != "" {
+		text = label + "\n" + text
+	}
+	_ = edge.Set("label", text)
+}
+
+// setResolvedURL labels the edge to a subscriber given by ref with the URL
+// it resolved to.
+func (g *Graph) setResolvedURL(edge *dot.Edge, dest *duckv1.Destination, uri *apis.URL) {
+	if !g.resolvedURLs || dest == nil || dest.Ref == nil || uri == nil {
+		return
+	}
+	appendEdgeLabel(edge, uri.String())
+}
+
+func flowTooltip(from, to string) string {
+	return fmt.Sprintf("events flow from %s to %s", from, to)
+}
+
+// sinkName returns the name of the resource addressed by dns, or dns itself
+// if it does not resolve to a known resource.
+func (g *Graph) sinkName(dns string) string {
+	dns = strings.TrimSuffix(dns, "/")
+	if key, ok := g.dnsToKey[dns]; ok {
+		return key[strings.LastIndex(key, "/")+1:]
+	}
+	return dns
+}
+
 func (g *Graph) getOrCreateSink(uri string) *dot.Node {
 	uri = strings.TrimSuffix(uri, "/")
 
-	var node *dot.Node
-	var key string
-	var ok bool
-	if key, ok = g.dnsToKey[uri]; !ok {
-		node = dot.NewNode("UnknownSink " + uri)
-		g.AddNode(node)
-		g.nodes[key] = node
+	if key, ok := g.dnsToKey[uri]; ok {
+		if node, ok := g.nodes[key]; ok {
+			return node
+		}
 	}
-	return g.nodes[key]
+	return g.getOrCreateUnknown("Sink", uriKey(uri), uri)
 }
 
-func (g *Graph) getOrCreateSubscriber(subscriber *duckv1.Destination) *dot.Node {
+func (g *Graph) getOrCreateSubscriber(ns string, subscriber *duckv1.Destination) *dot.Node {
 	key := "?"
 	label := "?"
 
 	if subscriber != nil {
-		key = destinationKey(subscriber)
+		key = g.destinationKey(ns, subscriber)
 		if subscriber.URI != nil {
 			label = subscriber.URI.String()
+			// Subscribers by address resolve to the channel or broker
+			// serving it.
+			if k, ok := g.dnsToKey[strings.TrimSuffix(label, "/")]; ok {
+				key = k
+			}
 		} else if subscriber.Ref != nil {
 			gv, _ := schema.ParseGroupVersion(subscriber.Ref.APIVersion)
 			label = fmt.Sprintf("%s\n%s\n%s",
@@ -477,29 +1736,116 @@ func (g *Graph) getOrCreateSubscriber(subscriber *duckv1.Destination) *dot.Node
 	var sub *dot.Node
 	var ok bool
 	if sub, ok = g.nodes[key]; !ok {
-		sub = dot.NewNode(label)
-		if subscriber != nil && subscriber.Ref != nil {
-			setNodeShapeForKind(sub, subscriber.Ref.Kind, subscriber.Ref.APIVersion)
+		if g.full() {
+			return nil
+		}
+		if subscriber == nil || subscriber.Ref == nil {
+			sub = dot.NewNode(escapeLabel(label))
+			g.track(key, sub)
+			g.addNodeTo(nil, sub)
+			return sub
 		}
 
-		g.nodes[key] = sub
-		g.AddNode(sub)
+		refNs := subscriber.Ref.Namespace
+		if refNs == "" {
+			refNs = ns
+		}
+		sub = g.newNode(refNs, escapeLabel(label))
+		setNodeShapeForKind(sub, subscriber.Ref.Kind, subscriber.Ref.APIVersion)
+
+		g.track(key, sub)
+		g.info[key] = nodeInfo{
+			kind:       subscriber.Ref.Kind,
+			apiVersion: subscriber.Ref.APIVersion,
+			namespace:  refNs,
+			name:       subscriber.Ref.Name,
+		}
+		g.addNodeTo(g.namespaceGroup(refNs), sub)
 	}
 	return sub
 }
 
-func (g *Graph) getOrCreateReply(dest *duckv1.Destination) *dot.Node {
+func (g *Graph) getOrCreateReply(ns string, dest *duckv1.Destination) *dot.Node {
 	if dest != nil {
-		ck := destinationKey(dest)
-		if cn, ok := g.nodes[ck]; !ok {
-			cn = dot.NewNode("Unknown Destination " + ck)
-		} else {
+		ck := g.destinationKey(ns, dest)
+		if dest.Ref == nil && dest.URI != nil {
+			// Replies by address resolve to the channel, broker or service
+			// serving it.
+			if key, ok := g.dnsToKey[strings.TrimSuffix(dest.URI.String(), "/")]; ok {
+				ck = key
+			}
+		}
+		if cn, ok := g.nodes[ck]; ok {
 			return cn
 		}
+		if dest.Ref != nil {
+			// Replies to a ref are drawn like subscribers, so a Service not
+			// added yet is shared with later references to it.
+			return g.getOrCreateSubscriber(ns, dest)
+		}
+		if dest.URI == nil {
+			return nil
+		}
+		return g.getOrCreateUnknown("Destination", ck, strings.TrimSuffix(dest.URI.String(), "/"))
 	}
 	return nil
 }
 
+// labelEscapes replaces the characters the dot package can write unescaped,
+// breaking the output, with entities graphviz decodes in labels.
+var labelEscapes = strings.NewReplacer(
+	"&", "&amp;",
+	`"`, "&quot;",
+	`\`, "&#92;",
+)
+
+// escapeLabel escapes s to be drawn as is in a label built from resource
+// names, addresses or filters. Newlines are kept and still break the label
+// into lines.
+func escapeLabel(s string) string {
+	return labelEscapes.Replace(s)
+}
+
+// labelUnescapes undoes labelEscapes.
+var labelUnescapes = strings.NewReplacer(
+	"&amp;", "&",
+	"&quot;", `"`,
+	"&#92;", `\`,
+)
+
+// unescapeLabel returns the text escapeLabel escaped, for formats other than
+// DOT that escape it their own way.
+func unescapeLabel(s string) string {
+	return labelUnescapes.Replace(s)
+}
+
+// getOrCreateUnknown returns the placeholder node tracked under key for the
+// kind of resource named name, creating it on first use, so every reference
+// to the same missing resource shares one node. It returns nil if the node
+// is missing and the graph is full.
+func (g *Graph) getOrCreateUnknown(kind, key, name string) *dot.Node {
+	if node, ok := g.nodes[key]; ok {
+		return node
+	}
+	if g.full() {
+		return nil
+	}
+	node := g.unknownNode(kind, name)
+	g.addNodeTo(nil, node)
+	g.track(key, node)
+	return node
+}
+
+// unknownNode returns a placeholder node for the kind of resource named name
+// that is referenced but not in the graph.
+func (g *Graph) unknownNode(kind, name string) *dot.Node {
+	prefix, ok := g.unknownPrefixes[kind]
+	if !ok {
+		prefix = "Unknown " + kind
+	}
+	return dot.NewNode(prefix + " " + name)
+}
+
 func sinkDNS(source duckv1.Source) string {
 	if source.Status.SinkURI != nil {
 		return strings.TrimSuffix(source.Status.SinkURI.String(), "/")
@@ -507,50 +1853,114 @@ func sinkDNS(source duckv1.Source) string {
 	return ""
 }
 
-func channelKey(name string) string {
-	return eventingKey("channel", name)
+func (g *Graph) channelKey(ns, name string) string {
+	return g.resourceKey("messaging.knative.dev", "Channel", ns, name)
+}
+
+func (g *Graph) inMemoryChannelKey(ns, name string) string {
+	return g.resourceKey("messaging.knative.dev", "InMemoryChannel", ns, name)
+}
+
+func (g *Graph) subscriptionKey(ns, name string) string {
+	return g.resourceKey("messaging.knative.dev", "Subscription", ns, name)
+}
+
+func (g *Graph) brokerKey(ns, name string) string {
+	return g.resourceKey("eventing.knative.dev", "Broker", ns, name)
+}
+
+func (g *Graph) triggerKey(ns, name string) string {
+	return g.resourceKey("eventing.knative.dev", "Trigger", ns, name)
+}
+
+func (g *Graph) eventTypeKey(ns, name string) string {
+	return g.resourceKey("eventing.knative.dev", "EventType", ns, name)
 }
 
-func inMemoryChannelKey(name string) string {
-	return messagingKey("inmemorychannel", name)
+func (g *Graph) sequenceKey(ns, name string) string {
+	return g.resourceKey("flows.knative.dev", "Sequence", ns, name)
 }
 
-func subscriptionKey(name string) string {
-	return eventingKey("subscription", name)
+func (g *Graph) parallelKey(ns, name string) string {
+	return g.resourceKey("flows.knative.dev", "Parallel", ns, name)
 }
 
-func brokerKey(name string) string {
-	return eventingKey("broker", name)
+func (g *Graph) parallelBranchKey(ns, name string, branch int) string {
+	return g.resourceKey("flows.knative.dev", "ParallelBranch", ns, fmt.Sprintf("%s-%d", name, branch))
 }
 
-func triggerKey(name string) string {
-	return eventingKey("trigger", name)
+func (g *Graph) sequenceStepKey(ns, name string, step int) string {
+	return g.resourceKey("flows.knative.dev", "SequenceStep", ns, fmt.Sprintf("%s-%d", name, step))
 }
 
-func sequenceKey(name string) string {
-	return messagingKey("sequence", name)
+func (g *Graph) sequenceReplyKey(ns, name string) string {
+	return g.resourceKey("flows.knative.dev", "SequenceReply", ns, name)
 }
 
-func sequenceStepKey(name string, step int) string {
-	return messagingKey("sequencestep", fmt.Sprintf("%s-%d", name, step))
+func (g *Graph) parallelReplyKey(ns, name string) string {
+	return g.resourceKey("flows.knative.dev", "ParallelReply", ns, name)
 }
 
-func destinationKey(dest *duckv1.Destination) string {
+// destinationKey returns the key of the resource dest refers to, in ns unless
+// the ref names its namespace, or of its URI.
+func (g *Graph) destinationKey(ns string, dest *duckv1.Destination) string {
 	if dest == nil {
 		return "unknown"
 	}
 	if dest.Ref != nil {
 		gv, _ := schema.ParseGroupVersion(dest.Ref.APIVersion)
+		if dest.Ref.Namespace != "" {
+			ns = dest.Ref.Namespace
+		}
+		return g.resourceKey(gv.Group, dest.Ref.Kind, ns, dest.Ref.Name)
+	}
+	return uriKey(dest.URI.String())
+}
+
+// subscribableKey returns the key of what a subscription subscribes to.
+// Sequences and Parallels can be subscribed to like channels, and are keyed
+// by kind alone since their group moved between releases.
+func (g *Graph) subscribableKey(gvk schema.GroupVersionKind, ns, name string) string {
+	switch gvk.Kind {
+	case "Sequence":
+		return g.sequenceKey(ns, name)
+	case "Parallel":
+		return g.parallelKey(ns, name)
+	}
+	return g.gvkKey(gvk, ns, name)
+}
+
+func (g *Graph) gvkKey(gvk schema.GroupVersionKind, ns, name string) string {
+	return g.resourceKey(gvk.Group, gvk.Kind, ns, name)
+}
 
-		fmt.Printf("destinationKey --> %s/%s/%s\n", gv.Group, dest.Ref.Kind, dest.Ref.Name)
+func (g *Graph) servingKey(kind, ns, name string) string {
+	return g.resourceKey("serving.knative.dev", kind, ns, name)
+}
 
-		return strings.ToLower(fmt.Sprintf("%s/%s/%s", gv.Group, dest.Ref.Kind, dest.Ref.Name))
+// resourceKey returns the key of the named resource, from the key function
+// if one is set. With namespace clusters the namespace is part of the key.
+func (g *Graph) resourceKey(group, kind, ns, name string) string {
+	if g.keyFunc != nil {
+		return g.keyFunc(schema.GroupVersionKind{Group: group, Kind: kind}, ns, name)
 	}
-	return uriKey(dest.URI.String())
+	if g.namespaceClusters && ns != "" {
+		return key(group, kind, ns+"/"+name)
+	}
+	return key(group, kind, name)
 }
 
-func gvkKey(gvk schema.GroupVersionKind, name string) string {
-	return strings.ToLower(fmt.Sprintf("%s/%s/%s", gvk.Group, gvk.Kind, name))
+// newNode returns the node named name for a resource in the namespace ns.
+// With namespace clusters the name is qualified by the namespace, so same
+// named resources in different namespaces are drawn apart, and name is
+// kept as the label.
+func (g *Graph) newNode(ns, name string) *dot.Node {
+	if !g.namespaceClusters || ns == "" {
+		return dot.NewNode(name)
+	}
+	n := dot.NewNode(ns + "/" + name)
+	_ = n.Set("label", name)
+	return n
 }
 
 func key(group, kind, name string) string {
@@ -564,15 +1974,3 @@ func uriKey(uri string) string {
 func refKey(group, kind, name string) string {
 	return strings.ToLower(fmt.Sprintf("%s/%s/%s", group, kind, name))
 }
-
-func eventingKey(kind, name string) string {
-	return key("eventing.knative.dev", kind, name)
-}
-
-func messagingKey(kind, name string) string {
-	return key("messaging.knative.dev", kind, name)
-}
-
-func servingKey(kind, name string) string {
-	return key("serving.knative.dev", kind, name)
-}
@@ -0,0 +1,1388 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tmc/dot"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	flowsv1beta1 "knative.dev/eventing/pkg/apis/flows/v1beta1"
+	messagingv1beta1 "knative.dev/eventing/pkg/apis/messaging/v1beta1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/tracker"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+func mustURL(s string) *apis.URL {
+	u, err := apis.ParseURL(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func newBroker(ns, name string) eventingv1beta1.Broker {
+	b := eventingv1beta1.Broker{
+		TypeMeta:   metav1.TypeMeta{Kind: "Broker", APIVersion: "eventing.knative.dev/v1beta1"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+	}
+	b.Status.Address.URL = mustURL(fmt.Sprintf("http://broker-ingress.knative-eventing.svc.cluster.local/%s/%s", ns, name))
+	return b
+}
+
+func newTrigger(ns, name, broker string, subscriber duckv1.Destination) eventingv1beta1.Trigger {
+	return eventingv1beta1.Trigger{
+		TypeMeta:   metav1.TypeMeta{Kind: "Trigger", APIVersion: "eventing.knative.dev/v1beta1"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+		Spec: eventingv1beta1.TriggerSpec{
+			Broker:     broker,
+			Subscriber: subscriber,
+		},
+	}
+}
+
+func newChannel(ns, name string) messagingv1beta1.InMemoryChannel {
+	c := messagingv1beta1.InMemoryChannel{
+		TypeMeta:   metav1.TypeMeta{Kind: "InMemoryChannel", APIVersion: "messaging.knative.dev/v1beta1"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+	}
+	c.Status.Address = &duckv1.Addressable{URL: mustURL(fmt.Sprintf("http://%s-kn-channel.%s.svc.cluster.local", name, ns))}
+	return c
+}
+
+func newSubscription(ns, name, channel string, subscriber, reply *duckv1.Destination) messagingv1beta1.Subscription {
+	return messagingv1beta1.Subscription{
+		TypeMeta:   metav1.TypeMeta{Kind: "Subscription", APIVersion: "messaging.knative.dev/v1beta1"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+		Spec: messagingv1beta1.SubscriptionSpec{
+			Channel: corev1.ObjectReference{
+				Kind:       "InMemoryChannel",
+				APIVersion: "messaging.knative.dev/v1beta1",
+				Name:       channel,
+			},
+			Subscriber: subscriber,
+			Reply:      reply,
+		},
+	}
+}
+
+func newKnService(ns, name string, env ...corev1.EnvVar) servingv1.Service {
+	s := servingv1.Service{
+		TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "serving.knative.dev/v1"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+	}
+	s.Spec.Template.Spec.Containers = []corev1.Container{{Env: env}}
+	s.Status.Address = &duckv1.Addressable{URL: mustURL(fmt.Sprintf("http://%s.%s.svc.cluster.local", name, ns))}
+	return s
+}
+
+func newSource(ns, name, sink string) duckv1.Source {
+	s := duckv1.Source{
+		TypeMeta:   metav1.TypeMeta{Kind: "PingSource", APIVersion: "sources.knative.dev/v1alpha2"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+	}
+	if sink != "" {
+		s.Status.SinkURI = mustURL(sink)
+	}
+	return s
+}
+
+func newSequence(ns, name string, steps ...string) flowsv1beta1.Sequence {
+	seq := flowsv1beta1.Sequence{
+		TypeMeta:   metav1.TypeMeta{Kind: "Sequence", APIVersion: "flows.knative.dev/v1beta1"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+	}
+	for _, step := range steps {
+		seq.Spec.Steps = append(seq.Spec.Steps, flowsv1beta1.SequenceStep{Destination: *serviceRef(step)})
+	}
+	seq.Status.Address = &duckv1.Addressable{URL: mustURL(fmt.Sprintf("http://%s-kn-sequence-0-kn-channel.%s.svc.cluster.local", name, ns))}
+	return seq
+}
+
+func newParallel(ns, name string, subscribers ...string) flowsv1beta1.Parallel {
+	p := flowsv1beta1.Parallel{
+		TypeMeta:   metav1.TypeMeta{Kind: "Parallel", APIVersion: "flows.knative.dev/v1beta1"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+	}
+	for _, subscriber := range subscribers {
+		p.Spec.Branches = append(p.Spec.Branches, flowsv1beta1.ParallelBranch{Subscriber: *serviceRef(subscriber)})
+	}
+	p.Status.Address = &duckv1.Addressable{URL: mustURL(fmt.Sprintf("http://%s-kn-parallel-kn-channel.%s.svc.cluster.local", name, ns))}
+	return p
+}
+
+func serviceRef(name string) *duckv1.Destination {
+	return &duckv1.Destination{Ref: &duckv1.KReference{
+		Kind:       "Service",
+		APIVersion: "serving.knative.dev/v1",
+		Name:       name,
+	}}
+}
+
+func channelRef(name string) *duckv1.Destination {
+	return &duckv1.Destination{Ref: &duckv1.KReference{
+		Kind:       "InMemoryChannel",
+		APIVersion: "messaging.knative.dev/v1beta1",
+		Name:       name,
+	}}
+}
+
+// edgesOf returns the drawn edges with the relationship rel.
+func edgesOf(g *Graph, rel string) []Edge {
+	var edges []Edge
+	for _, e := range g.edges {
+		if e.rel == rel {
+			edges = append(edges, g.edgeFor(e))
+		}
+	}
+	return edges
+}
+
+// sinksOf returns the keys of the sinks of the node with key from.
+func sinksOf(g *Graph, from string) []string {
+	var sinks []string
+	for _, e := range edgesOf(g, relSink) {
+		if e.From == from {
+			sinks = append(sinks, e.To)
+		}
+	}
+	return sinks
+}
+
+// findEdge returns the drawn edge from the node with key from to the node
+// with key to.
+func findEdge(t *testing.T, g *Graph, from, to string) *edge {
+	t.Helper()
+	for _, e := range g.edges {
+		if e.Source() == g.nodes[from] && e.Destination() == g.nodes[to] {
+			return e
+		}
+	}
+	t.Fatalf("no edge from %q to %q", from, to)
+	return nil
+}
+
+func TestAddSubscriptionSharedTarget(t *testing.T) {
+	tests := []struct {
+		name      string
+		reply     *duckv1.Destination
+		wantEdges int
+		merged    bool
+	}{{
+		name:      "same target",
+		reply:     serviceRef("svc"),
+		wantEdges: 1,
+		merged:    true,
+	}, {
+		name:      "different targets",
+		reply:     channelRef("other"),
+		wantEdges: 2,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddInMemoryChannel(newChannel("default", "ch"))
+			g.AddInMemoryChannel(newChannel("default", "other"))
+			g.AddSubscription(newSubscription("default", "sub", "ch", serviceRef("svc"), tt.reply))
+
+			out := g.String()
+			if got := strings.Count(out, `" -> "`); got != tt.wantEdges {
+				t.Errorf("drew %d edges, want %d:\n%s", got, tt.wantEdges, out)
+			}
+			if got := strings.Contains(out, "subscribe+reply"); got != tt.merged {
+				t.Errorf("merged edge drawn = %v, want %v:\n%s", got, tt.merged, out)
+			}
+		})
+	}
+}
+
+func newEventType(ns, name, broker, eventType string) eventingv1beta1.EventType {
+	return eventingv1beta1.EventType{
+		TypeMeta:   metav1.TypeMeta{Kind: "EventType", APIVersion: "eventing.knative.dev/v1beta1"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+		Spec: eventingv1beta1.EventTypeSpec{
+			Type:   eventType,
+			Broker: broker,
+		},
+	}
+}
+
+func TestAddEventType(t *testing.T) {
+	tests := []struct {
+		name   string
+		broker bool
+	}{
+		{name: "known broker", broker: true},
+		{name: "missing broker", broker: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			if tt.broker {
+				g.AddBroker(newBroker("default", "default"))
+			}
+			g.AddEventType(newEventType("default", "et", "default", "dev.example.created"))
+
+			e := findEdge(t, g, "eventing.knative.dev/eventtype/et", "eventing.knative.dev/broker/default")
+			if got := e.Get("style"); got != "dashed" {
+				t.Errorf("event type edge style = %q, want dashed", got)
+			}
+		})
+	}
+}
+
+func TestEdgeIDsDeterministic(t *testing.T) {
+	build := func() []string {
+		g := New("default")
+		g.AddBroker(newBroker("default", "default"))
+		g.AddTrigger(newTrigger("default", "t1", "default", *serviceRef("svc")))
+		g.AddTrigger(newTrigger("default", "t2", "default", *serviceRef("svc")))
+		g.AddSource(newSource("default", "ping", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"))
+		g.AddSource(newSource("default", "ping2", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"))
+		var ids []string
+		for _, e := range g.edges {
+			ids = append(ids, e.Get("id"))
+		}
+		return ids
+	}
+
+	first, second := build(), build()
+	if len(first) != len(second) {
+		t.Fatalf("builds drew %d and %d edges", len(first), len(second))
+	}
+	seen := make(map[string]bool)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("edge %d has id %q, then %q", i, first[i], second[i])
+		}
+		if first[i] == "" || seen[first[i]] {
+			t.Errorf("edge %d has blank or repeated id %q", i, first[i])
+		}
+		seen[first[i]] = true
+	}
+}
+
+func TestSubscriptionReplyChainsChannels(t *testing.T) {
+	g := New("default")
+	g.AddInMemoryChannel(newChannel("default", "first"))
+	g.AddInMemoryChannel(newChannel("default", "second"))
+	g.AddSubscription(newSubscription("default", "to-second", "first", serviceRef("svc"), channelRef("second")))
+	g.AddSubscription(newSubscription("default", "from-second", "second", serviceRef("sink"), nil))
+
+	second := g.subgraphs["messaging.knative.dev/inmemorychannel/second"]
+	e := findEdge(t, g, "messaging.knative.dev/subscription/to-second", "messaging.knative.dev/inmemorychannel/second")
+	if e.rel != relReply {
+		t.Errorf("edge into the second channel is a %q edge, want %q", e.rel, relReply)
+	}
+	if got := e.Get("lhead"); got != second.Name() {
+		t.Errorf("reply edge ends at %q, want the second channel cluster %q", got, second.Name())
+	}
+	if !strings.Contains(second.String(), "Subscription from-second") {
+		t.Error("subscription of the second channel is not in its cluster")
+	}
+}
+
+func TestSelfLoop(t *testing.T) {
+	g := New("default")
+	g.AddInMemoryChannel(newChannel("default", "ch"))
+	self := &duckv1.Destination{Ref: &duckv1.KReference{
+		Kind:       "Subscription",
+		APIVersion: "messaging.knative.dev/v1beta1",
+		Name:       "sub",
+	}}
+	g.AddSubscription(newSubscription("default", "sub", "ch", self, nil))
+
+	key := "messaging.knative.dev/subscription/sub"
+	e := findEdge(t, g, key, key)
+	for attr, want := range map[string]string{
+		"tailport": "ne",
+		"headport": "se",
+		"style":    "dashed",
+		"color":    "red",
+	} {
+		if got := e.Get(attr); got != want {
+			t.Errorf("self-loop %s = %q, want %q", attr, got, want)
+		}
+	}
+	if got, want := g.Warnings(), []string{key + " references itself"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("Warnings() = %q, want %q", got, want)
+	}
+}
+
+func TestSubscriptionChannelKind(t *testing.T) {
+	tests := []struct {
+		name string
+		kind string
+		want string
+	}{
+		{name: "in memory", kind: "InMemoryChannel", want: "Subscription sub\non InMemoryChannel"},
+		{name: "kafka", kind: "KafkaChannel", want: "Subscription sub\non KafkaChannel"},
+		{name: "no kind", kind: "", want: "Subscription sub"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			sub := newSubscription("default", "sub", "ch", serviceRef("svc"), nil)
+			sub.Spec.Channel.Kind = tt.kind
+			g.AddSubscription(sub)
+
+			n := g.nodes["messaging.knative.dev/subscription/sub"]
+			got := n.Get("label")
+			if got == "" {
+				// Unlabeled nodes are drawn with their name.
+				got = n.Name()
+			}
+			if got != tt.want {
+				t.Errorf("label = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnServiceKSinkBroker(t *testing.T) {
+	address := "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "as registered", value: address},
+		{name: "trailing slash", value: address + "/"},
+		{name: "explicit port", value: "http://broker-ingress.knative-eventing.svc.cluster.local:80/default/default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			g.AddKnService(newKnService("default", "svc", corev1.EnvVar{Name: "K_SINK", Value: tt.value}))
+
+			e := findEdge(t, g, "serving.knative.dev/service/svc", "eventing.knative.dev/broker/default")
+			if e.rel != relSink {
+				t.Errorf("edge into the broker is a %q edge, want %q", e.rel, relSink)
+			}
+			if got, want := e.Get("lhead"), g.subgraphs["eventing.knative.dev/broker/default"].Name(); got != want {
+				t.Errorf("sink edge ends at %q, want the broker cluster %q", got, want)
+			}
+		})
+	}
+}
+
+func TestKnServiceSinksSorted(t *testing.T) {
+	first := corev1.EnvVar{Name: "SINK", Value: "http://a.default.svc.cluster.local"}
+	second := corev1.EnvVar{Name: "TARGET", Value: "http://b.default.svc.cluster.local"}
+	tests := []struct {
+		name string
+		env  []corev1.EnvVar
+	}{
+		{name: "in order", env: []corev1.EnvVar{first, second}},
+		{name: "reversed", env: []corev1.EnvVar{second, first}},
+	}
+	want := []Edge{
+		{From: "serving.knative.dev/service/svc", To: "serving.knative.dev/service/a", Relationship: relSink},
+		{From: "serving.knative.dev/service/svc", To: "serving.knative.dev/service/b", Relationship: relSink},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.LoadKnService(newKnService("default", "a"))
+			g.LoadKnService(newKnService("default", "b"))
+			g.AddKnService(newKnService("default", "svc", tt.env...))
+
+			got := edgesOf(g, relSink)
+			if len(got) != len(want) {
+				t.Fatalf("got sink edges %v, want %v", got, want)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("sink edge %d = %v, want %v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAddSequenceUnaddressed(t *testing.T) {
+	g := New("default")
+	first := newSequence("default", "first", "a")
+	first.Status.Address = nil
+	first.Spec.Reply = serviceRef("b")
+	second := newSequence("default", "second", "a")
+	second.Status.Address = nil
+
+	if plan := g.Plan(&first); len(plan) != 1 {
+		t.Errorf("Plan() = %v, want one entry", plan)
+	}
+	g.AddSequence(first)
+	g.Upsert(&first)
+	g.Upsert(&second)
+
+	start, other := g.nodes["flows.knative.dev/sequence/first"], g.nodes["flows.knative.dev/sequence/second"]
+	if start == nil || other == nil || start.Name() == other.Name() {
+		t.Errorf("unaddressed sequences are not drawn apart")
+	}
+	if err := g.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestAddParallel(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "default"))
+	g.AddKnService(newKnService("default", "own"))
+	p := newParallel("default", "par", "first", "second")
+	p.Spec.Branches[1].Reply = serviceRef("own")
+	p.Spec.Reply = serviceRef("last")
+	g.AddParallel(p)
+	g.AddTrigger(newTrigger("default", "t", "default", duckv1.Destination{Ref: &duckv1.KReference{
+		Kind:       "Parallel",
+		APIVersion: "flows.knative.dev/v1beta1",
+		Name:       "par",
+	}}))
+	g.AddSource(newSource("default", "ping", "http://par-kn-parallel-kn-channel.default.svc.cluster.local"))
+
+	start := "flows.knative.dev/parallel/par"
+	for _, e := range []struct{ from, to, rel string }{
+		{"eventing.knative.dev/trigger/t", start, relSubscriber},
+		{"sources.knative.dev/pingsource/ping", start, relSink},
+		{start, "flows.knative.dev/parallelbranch/par-0", relStep},
+		{start, "flows.knative.dev/parallelbranch/par-1", relStep},
+		{"flows.knative.dev/parallelbranch/par-0", "serving.knative.dev/service/first", relSubscriber},
+		{"flows.knative.dev/parallelbranch/par-1", "serving.knative.dev/service/second", relSubscriber},
+		{"flows.knative.dev/parallelbranch/par-1", "serving.knative.dev/service/own", relReply},
+	} {
+		if got := findEdge(t, g, e.from, e.to); got.rel != e.rel {
+			t.Errorf("edge from %s to %s is a %q edge, want %q", e.from, e.to, got.rel, e.rel)
+		}
+	}
+	if got := g.parent[g.nodes[start]]; got != g.subgraphs[start] {
+		t.Error("start node is not in the parallel cluster")
+	}
+	for k := range g.nodes {
+		if strings.HasPrefix(k, "messaging.knative.dev/") || strings.Contains(g.nodes[k].Name(), "Unknown") {
+			t.Errorf("unexpected node %s", k)
+		}
+	}
+}
+
+func TestBrokerClassShapes(t *testing.T) {
+	tests := []struct {
+		class string
+		want  string
+	}{
+		{class: "MTChannelBasedBroker", want: "oval"},
+		{class: "Kafka", want: "cylinder"},
+		{class: "RabbitMQBroker", want: "hexagon"},
+		{class: "", want: "oval"},
+		{class: "SomethingElse", want: "oval"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.class, func(t *testing.T) {
+			g := New("default")
+			broker := newBroker("default", "default")
+			if tt.class != "" {
+				broker.Annotations = map[string]string{BrokerClassAnnotation: tt.class}
+			}
+			g.AddBroker(broker)
+
+			if got := g.nodes["eventing.knative.dev/broker/default"].Get("shape"); got != tt.want {
+				t.Errorf("shape = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddChannelBackingKind(t *testing.T) {
+	tests := []struct {
+		name      string
+		kind      string
+		wantShape string
+		wantLabel string
+	}{
+		{name: "in memory", kind: "InMemoryChannel", wantShape: "oval", wantLabel: "(InMemoryChannel)"},
+		{name: "kafka", kind: "KafkaChannel", wantShape: "cylinder", wantLabel: "(KafkaChannel)"},
+		{name: "other", kind: "OtherChannel", wantShape: "oval", wantLabel: "(OtherChannel)"},
+		{name: "no template", wantShape: "oval"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			channel := messagingv1beta1.Channel{
+				TypeMeta:   metav1.TypeMeta{Kind: "Channel", APIVersion: "messaging.knative.dev/v1beta1"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ch"},
+			}
+			if tt.kind != "" {
+				channel.Spec.ChannelTemplate = &messagingv1beta1.ChannelTemplateSpec{
+					TypeMeta: metav1.TypeMeta{Kind: tt.kind, APIVersion: "messaging.knative.dev/v1beta1"},
+				}
+			}
+			g.AddChannel(channel)
+
+			key := "messaging.knative.dev/channel/ch"
+			if got := g.nodes[key].Get("shape"); got != tt.wantShape {
+				t.Errorf("shape = %q, want %q", got, tt.wantShape)
+			}
+			label := g.subgraphs[key].Get("label")
+			if tt.wantLabel != "" && !strings.HasSuffix(label, tt.wantLabel) {
+				t.Errorf("cluster label %q does not end with %q", label, tt.wantLabel)
+			}
+			if tt.wantLabel == "" && strings.Contains(label, "(") {
+				t.Errorf("cluster label %q names a backing kind", label)
+			}
+		})
+	}
+}
+
+func TestAddKnServiceV1(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiVersion string
+	}{
+		{name: "v1", apiVersion: "serving.knative.dev/v1"},
+		{name: "v1beta1", apiVersion: "serving.knative.dev/v1beta1"},
+		{name: "no type meta", apiVersion: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			svc := newKnService("default", "svc", corev1.EnvVar{
+				Name:  "SINK",
+				Value: "http://broker-ingress.knative-eventing.svc.cluster.local/default/default",
+			})
+			svc.APIVersion = tt.apiVersion
+			if tt.apiVersion == "" {
+				svc.Kind = ""
+			}
+			g.AddKnService(svc)
+
+			key := "serving.knative.dev/service/svc"
+			if got := g.nodes[key].Get("shape"); got != "septagon" {
+				t.Errorf("shape = %q, want septagon", got)
+			}
+			if e := findEdge(t, g, key, "eventing.knative.dev/broker/default"); e.rel != relSink {
+				t.Errorf("edge into the broker is a %q edge, want %q", e.rel, relSink)
+			}
+		})
+	}
+}
+
+func TestAddKnServiceAfterReference(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "default"))
+	g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+
+	key := "serving.knative.dev/service/svc"
+	if got := g.info[key].namespace; got != "default" {
+		t.Errorf("placeholder namespace = %q, want default", got)
+	}
+
+	created := metav1.NewTime(time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC))
+	svc := newKnService("default", "svc")
+	svc.CreationTimestamp = created
+	g.AddKnService(svc)
+	if got := g.info[key].created; !got.Equal(created.Time) {
+		t.Errorf("created = %v, want %v from the added service", got, created.Time)
+	}
+}
+
+// quotesBalanced reports whether every quoted string in the DOT source out
+// is closed, as graphviz needs to parse it.
+func quotesBalanced(out string) bool {
+	quoted := false
+	for i := 0; i < len(out); i++ {
+		switch out[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		}
+	}
+	return !quoted
+}
+
+func TestEscapeLabels(t *testing.T) {
+	tests := []struct {
+		name string
+		add  func(g *Graph)
+		key  string
+		want string
+	}{{
+		name: "event type with quotes",
+		add: func(g *Graph) {
+			g.AddEventType(newEventType("default", "et", "default", `dev."quoted"`))
+		},
+		key:  "eventing.knative.dev/eventtype/et",
+		want: "dev.&quot;quoted&quot;",
+	}, {
+		name: "event type with a backslash and schema",
+		add: func(g *Graph) {
+			et := newEventType("default", "et", "default", `dev.back\slash`)
+			et.Spec.Schema = mustURL("http://schemas.example.com/a&b")
+			g.AddEventType(et)
+		},
+		key:  "eventing.knative.dev/eventtype/et",
+		want: "dev.back&#92;slash\nhttp://schemas.example.com/a&amp;b",
+	}, {
+		name: "trigger filter with quotes",
+		add: func(g *Graph) {
+			tr := newTrigger("default", "t", "default", *serviceRef("svc"))
+			tr.Spec.Filter = &eventingv1beta1.TriggerFilter{Attributes: map[string]string{"type": `"quoted"`}}
+			g.AddTrigger(tr)
+		},
+		key:  "eventing.knative.dev/trigger/t",
+		want: "Trigger t\ntype: &quot;quoted&quot;",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			tt.add(g)
+
+			if got := g.nodes[tt.key].Get("label"); got != tt.want {
+				t.Errorf("label = %q, want %q", got, tt.want)
+			}
+			if out := g.String(); !quotesBalanced(out) {
+				t.Errorf("rendered DOT has an unclosed quote:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestSubscriptionReplyService(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply *duckv1.Destination
+	}{
+		{name: "ref", reply: serviceRef("replies")},
+		{name: "address", reply: &duckv1.Destination{URI: mustURL("http://replies.default.svc.cluster.local")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.LoadKnService(newKnService("default", "replies"))
+			g.AddInMemoryChannel(newChannel("default", "ch"))
+			g.AddSubscription(newSubscription("default", "sub", "ch", serviceRef("svc"), tt.reply))
+
+			e := findEdge(t, g, "messaging.knative.dev/subscription/sub", "serving.knative.dev/service/replies")
+			if e.rel != relReply {
+				t.Errorf("edge into the service is a %q edge, want %q", e.rel, relReply)
+			}
+			if got, want := len(g.nodes), 4; got != want {
+				t.Errorf("graph tracks %d nodes, want %d: %v", got, want, g.nodes)
+			}
+		})
+	}
+}
+
+func TestUnknownSinkShared(t *testing.T) {
+	tests := []struct {
+		name   string
+		second string
+	}{
+		{name: "same address", second: "http://missing.example.com"},
+		{name: "trailing slash", second: "http://missing.example.com/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddKnService(newKnService("default", "a", corev1.EnvVar{Name: "SINK", Value: "http://missing.example.com"}))
+			g.AddKnService(newKnService("default", "b", corev1.EnvVar{Name: "SINK", Value: tt.second}))
+
+			unknown := 0
+			for _, n := range g.order {
+				if strings.HasPrefix(n.Name(), "Unknown Sink") {
+					unknown++
+				}
+			}
+			if unknown != 1 {
+				t.Errorf("drew %d unknown sinks, want 1", unknown)
+			}
+			for _, from := range []string{"a", "b"} {
+				findEdge(t, g, "serving.knative.dev/service/"+from, "uri/http://missing.example.com")
+			}
+		})
+	}
+}
+
+func TestSubscriptionReplyUnknownChannel(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply *duckv1.Destination
+		want  string
+		drawn string // prefix of the node name of placeholders
+	}{{
+		name:  "known channel ref",
+		reply: channelRef("known"),
+		want:  "messaging.knative.dev/inmemorychannel/known",
+	}, {
+		name:  "known channel address",
+		reply: &duckv1.Destination{URI: mustURL("http://known-kn-channel.default.svc.cluster.local")},
+		want:  "messaging.knative.dev/inmemorychannel/known",
+	}, {
+		name:  "unknown channel ref",
+		reply: channelRef("gone"),
+		want:  "messaging.knative.dev/inmemorychannel/gone",
+		drawn: "gone\nInMemoryChannel",
+	}, {
+		name:  "unknown channel address",
+		reply: &duckv1.Destination{URI: mustURL("http://gone-kn-channel.default.svc.cluster.local")},
+		want:  "uri/http://gone-kn-channel.default.svc.cluster.local",
+		drawn: "Unknown ",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddInMemoryChannel(newChannel("default", "first"))
+			g.AddInMemoryChannel(newChannel("default", "known"))
+			g.AddSubscription(newSubscription("default", "sub", "first", serviceRef("svc"), tt.reply))
+
+			want := []Edge{{From: "messaging.knative.dev/subscription/sub", To: tt.want, Relationship: relReply}}
+			if got := edgesOf(g, relReply); !reflect.DeepEqual(got, want) {
+				t.Errorf("reply edges = %v, want %v", got, want)
+			}
+			n, ok := g.nodes[tt.want]
+			if !ok {
+				t.Fatalf("reply target %q is not registered", tt.want)
+			}
+			if !strings.HasPrefix(n.Name(), tt.drawn) {
+				t.Errorf("reply target is drawn as %q, want a name starting with %q", n.Name(), tt.drawn)
+			}
+		})
+	}
+}
+
+func TestEdgesClipToClusters(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+	}{{
+		name: "source to channel",
+		from: "sources.knative.dev/pingsource/to-channel",
+		to:   "messaging.knative.dev/inmemorychannel/ch",
+	}, {
+		name: "source to broker",
+		from: "sources.knative.dev/pingsource/to-broker",
+		to:   "eventing.knative.dev/broker/default",
+	}, {
+		name: "reply to broker",
+		from: "messaging.knative.dev/subscription/sub",
+		to:   "eventing.knative.dev/broker/default",
+	}}
+	g := New("default")
+	g.AddInMemoryChannel(newChannel("default", "ch"))
+	g.AddBroker(newBroker("default", "default"))
+	g.AddSource(newSource("default", "to-channel", "http://ch-kn-channel.default.svc.cluster.local"))
+	g.AddSource(newSource("default", "to-broker", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"))
+	reply := &duckv1.Destination{URI: mustURL("http://broker-ingress.knative-eventing.svc.cluster.local/default/default")}
+	g.AddSubscription(newSubscription("default", "sub", "ch", serviceRef("svc"), reply))
+
+	if !strings.Contains(g.String(), "compound=true;") {
+		t.Fatalf("graph is not compound, lhead is ignored:\n%s", g.String())
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := findEdge(t, g, tt.from, tt.to)
+			if got, want := e.Get("lhead"), g.subgraphs[tt.to].Name(); got != want {
+				t.Errorf("edge ends at %q, want the cluster %q", got, want)
+			}
+			if got := e.Get("ltail"); got != "" {
+				t.Errorf("edge leaves the cluster %q, want it to leave its node", got)
+			}
+		})
+	}
+}
+
+func TestTriggerToChannel(t *testing.T) {
+	const (
+		channel = "messaging.knative.dev/inmemorychannel/ch"
+		trigger = "eventing.knative.dev/trigger/t"
+		sub     = "messaging.knative.dev/subscription/sub"
+		broker  = "eventing.knative.dev/broker/default"
+	)
+	tests := []struct {
+		name       string
+		subscriber *duckv1.Destination
+	}{
+		{name: "channel ref", subscriber: channelRef("ch")},
+		{name: "channel address", subscriber: &duckv1.Destination{URI: mustURL("http://ch-kn-channel.default.svc.cluster.local")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Channels are added before the triggers, as the loaders do.
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			g.AddInMemoryChannel(newChannel("default", "ch"))
+			g.AddTrigger(newTrigger("default", "t", "default", *tt.subscriber))
+			reply := &duckv1.Destination{URI: mustURL("http://broker-ingress.knative-eventing.svc.cluster.local/default/default")}
+			g.AddSubscription(newSubscription("default", "sub", "ch", serviceRef("svc"), reply))
+
+			cluster := g.subgraphs[channel]
+			if got := findEdge(t, g, trigger, channel).Get("lhead"); got != cluster.Name() {
+				t.Errorf("trigger edge ends at %q, want the channel cluster %q", got, cluster.Name())
+			}
+			if g.parent[g.nodes[sub]] != cluster {
+				t.Error("subscription of the channel is not in its cluster")
+			}
+			if got := findEdge(t, g, sub, broker).rel; got != relReply {
+				t.Errorf("edge back to the broker is a %q edge, want %q", got, relReply)
+			}
+		})
+	}
+}
+
+func TestUnknownNodesDeduplicated(t *testing.T) {
+	const sink = "uri/http://missing.example.com"
+	addSource := func(g *Graph) { g.AddSource(newSource("default", "ping", "http://missing.example.com")) }
+	addService := func(g *Graph) {
+		g.AddKnService(newKnService("default", "svc", corev1.EnvVar{Name: "K_SINK", Value: "http://missing.example.com/"}))
+	}
+	addTriggers := func(g *Graph) {
+		g.AddTrigger(newTrigger("default", "t1", "gone", *serviceRef("sub")))
+		g.AddTrigger(newTrigger("default", "t2", "gone", *serviceRef("sub")))
+	}
+	tests := []struct {
+		name   string
+		add    []func(*Graph)
+		prefix string
+		want   []Edge
+	}{{
+		name:   "source then service",
+		add:    []func(*Graph){addSource, addService},
+		prefix: "Unknown Sink ",
+		want: []Edge{
+			{From: "sources.knative.dev/pingsource/ping", To: sink, Relationship: relSink},
+			{From: "serving.knative.dev/service/svc", To: sink, Relationship: relSink},
+		},
+	}, {
+		name:   "service then source",
+		add:    []func(*Graph){addService, addSource},
+		prefix: "Unknown Sink ",
+		want: []Edge{
+			{From: "serving.knative.dev/service/svc", To: sink, Relationship: relSink},
+			{From: "sources.knative.dev/pingsource/ping", To: sink, Relationship: relSink},
+		},
+	}, {
+		name:   "triggers of a missing broker",
+		add:    []func(*Graph){addTriggers},
+		prefix: "Unknown Broker ",
+		want: []Edge{
+			{From: "eventing.knative.dev/broker/gone", To: "eventing.knative.dev/trigger/t1", Relationship: relTrigger},
+			{From: "eventing.knative.dev/broker/gone", To: "eventing.knative.dev/trigger/t2", Relationship: relTrigger},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			for _, add := range tt.add {
+				add(g)
+			}
+			unknown := 0
+			for _, n := range g.order {
+				if strings.HasPrefix(n.Name(), tt.prefix) {
+					unknown++
+				}
+			}
+			if unknown != 1 {
+				t.Errorf("drew %d nodes named %q..., want 1", unknown, tt.prefix)
+			}
+			if got := edgesOf(g, tt.want[0].Relationship); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("edges = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodesAndEdges(t *testing.T) {
+	tests := []struct {
+		name      string
+		add       func(g *Graph)
+		wantNodes int
+		wantEdges int
+	}{{
+		name:      "empty",
+		add:       func(*Graph) {},
+		wantNodes: 0,
+		wantEdges: 0,
+	}, {
+		name: "broker",
+		add: func(g *Graph) {
+			g.AddBroker(newBroker("default", "default"))
+		},
+		wantNodes: 1,
+		wantEdges: 0,
+	}, {
+		name: "broker, trigger and subscriber",
+		add: func(g *Graph) {
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+		},
+		wantNodes: 3,
+		wantEdges: 2,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			tt.add(g)
+
+			nodes := g.Nodes()
+			if got := len(nodes); got != tt.wantNodes {
+				t.Errorf("Nodes() has %d nodes, want %d: %v", got, tt.wantNodes, nodes)
+			}
+			if got := len(g.Edges()); got != tt.wantEdges {
+				t.Errorf("Edges() has %d edges, want %d: %v", got, tt.wantEdges, g.Edges())
+			}
+			// The returned map is a copy.
+			nodes["eventing.knative.dev/broker/other"] = nil
+			delete(nodes, "eventing.knative.dev/broker/default")
+			if got := len(g.Nodes()); got != tt.wantNodes {
+				t.Errorf("changing the Nodes() map changed the graph to %d nodes", got)
+			}
+		})
+	}
+}
+
+func TestChannelShapeFromStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		status   string
+		want     string
+	}{
+		{name: "kafka from status", status: "KafkaChannel", want: "cylinder"},
+		{name: "natss from status", status: "NatssChannel", want: "doubleoctagon"},
+		{name: "template over status", template: "InMemoryChannel", status: "KafkaChannel", want: "oval"},
+		{name: "kafka template", template: "KafkaChannel", want: "cylinder"},
+		{name: "unknown kind", status: "OtherChannel", want: "oval"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			channel := messagingv1beta1.Channel{
+				TypeMeta:   metav1.TypeMeta{Kind: "Channel", APIVersion: "messaging.knative.dev/v1beta1"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ch"},
+			}
+			if tt.template != "" {
+				channel.Spec.ChannelTemplate = &messagingv1beta1.ChannelTemplateSpec{
+					TypeMeta: metav1.TypeMeta{Kind: tt.template, APIVersion: "messaging.knative.dev/v1alpha1"},
+				}
+			}
+			if tt.status != "" {
+				channel.Status.Channel = &duckv1.KReference{Kind: tt.status, APIVersion: "messaging.knative.dev/v1alpha1", Name: "ch"}
+			}
+			g.AddChannel(channel)
+
+			if got := g.nodes["messaging.knative.dev/channel/ch"].Get("shape"); got != tt.want {
+				t.Errorf("shape = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptionToFlow(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       string
+		apiVersion string
+		cluster    string
+	}{
+		{name: "parallel", kind: "Parallel", apiVersion: "flows.knative.dev/v1beta1", cluster: "flows.knative.dev/parallel/flow"},
+		{name: "parallel in messaging", kind: "Parallel", apiVersion: "messaging.knative.dev/v1alpha1", cluster: "flows.knative.dev/parallel/flow"},
+		{name: "sequence", kind: "Sequence", apiVersion: "flows.knative.dev/v1beta1", cluster: "flows.knative.dev/sequence/flow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddParallel(newParallel("default", "flow", "first"))
+			g.AddSequence(newSequence("default", "flow", "first"))
+			sub := newSubscription("default", "sub", "flow", serviceRef("svc"), nil)
+			sub.Spec.Channel = corev1.ObjectReference{Kind: tt.kind, APIVersion: tt.apiVersion, Name: "flow"}
+			g.AddSubscription(sub)
+
+			sn := g.nodes["messaging.knative.dev/subscription/sub"]
+			if g.parent[sn] != g.subgraphs[tt.cluster] {
+				t.Errorf("subscription is not in the %s cluster", tt.kind)
+			}
+		})
+	}
+}
+
+func TestConcurrentBuild(t *testing.T) {
+	// Each producer mutates the graph while others add to and render it.
+	tests := []struct {
+		name    string
+		produce func(g *Graph, i int)
+	}{{
+		name: "add",
+		produce: func(g *Graph, i int) {
+			g.AddTrigger(newTrigger("default", fmt.Sprintf("t%d", i), "default", *serviceRef("svc")))
+		},
+	}, {
+		name: "add nodes and edges",
+		produce: func(g *Graph, i int) {
+			n := dot.NewNode(fmt.Sprintf("n%d", i))
+			g.AddNode(n)
+			g.AddSubgraph(dot.NewSubgraph(fmt.Sprintf("cluster_n%d", i)))
+			g.AddEdge(dot.NewEdge(n, n))
+		},
+	}, {
+		name: "restyle",
+		produce: func(g *Graph, i int) {
+			g.SetOpacity("eventing.knative.dev/broker/default", 0.5)
+			g.ApplyPositions(map[string][2]float64{"eventing.knative.dev/broker/default": {float64(i), 0}})
+			g.Focus("eventing.knative.dev/broker/default")
+			g.IncludeOnly(schema.GroupVersionKind{Group: "eventing.knative.dev", Kind: "Broker"})
+			g.AddLegend()
+		},
+	}, {
+		name: "remove",
+		produce: func(g *Graph, i int) {
+			trigger := newTrigger("default", fmt.Sprintf("t%d", i), "default", *serviceRef("svc"))
+			g.Upsert(&trigger)
+			g.Delete(&trigger)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					g.AddKnService(newKnService("default", fmt.Sprintf("svc%d", i)))
+					tt.produce(g, i)
+				}(i)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_ = g.String()
+				}()
+			}
+			wg.Wait()
+
+			for i := 0; i < 8; i++ {
+				if _, ok := g.nodes[fmt.Sprintf("serving.knative.dev/service/svc%d", i)]; !ok {
+					t.Errorf("service svc%d was not added", i)
+				}
+			}
+		})
+	}
+}
+
+func TestTriggerFilterLabel(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]string
+		want       string
+	}{{
+		name: "no filter",
+		want: "",
+	}, {
+		name:       "empty filter",
+		attributes: map[string]string{},
+		want:       "",
+	}, {
+		name:       "type",
+		attributes: map[string]string{"type": "dev.example.created"},
+		want:       "Trigger t\ntype: dev.example.created",
+	}, {
+		name:       "sorted",
+		attributes: map[string]string{"type": "dev.example.created", "source": "ping", "extension": "x"},
+		want:       "Trigger t\nextension: x\nsource: ping\ntype: dev.example.created",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trigger := newTrigger("default", "t", "default", *serviceRef("svc"))
+			if tt.attributes != nil {
+				trigger.Spec.Filter = &eventingv1beta1.TriggerFilter{Attributes: tt.attributes}
+			}
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(trigger)
+
+			if got := g.nodes["eventing.knative.dev/trigger/t"].Get("label"); got != tt.want {
+				t.Errorf("label = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddPingSource(t *testing.T) {
+	const source = "sources.knative.dev/pingsource/ping"
+	tests := []struct {
+		name     string
+		sink     string
+		schedule string
+		data     string
+		want     string
+		wantSink string
+	}{{
+		name:     "schedule",
+		sink:     "http://broker-ingress.knative-eventing.svc.cluster.local/default/default",
+		schedule: "*/2 * * * *",
+		want:     "Source ping\nPingSource\nsources.knative.dev\nschedule: */2 * * * *",
+		wantSink: "eventing.knative.dev/broker/default",
+	}, {
+		name:     "data",
+		sink:     "http://broker-ingress.knative-eventing.svc.cluster.local/default/default",
+		schedule: "@hourly",
+		data:     `{"msg": "tick"}`,
+		want:     "Source ping\nPingSource\nsources.knative.dev\nschedule: @hourly\ndata: {&quot;msg&quot;: &quot;tick&quot;}",
+		wantSink: "eventing.knative.dev/broker/default",
+	}, {
+		name:     "no sink yet",
+		schedule: "@hourly",
+		want:     "Source ping\nPingSource\nsources.knative.dev\nschedule: @hourly",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			g.AddPingSource(newSource("default", "ping", tt.sink), tt.schedule, tt.data)
+
+			n := g.nodes[source]
+			if got := n.Get("label"); got != tt.want {
+				t.Errorf("label = %q, want %q", got, tt.want)
+			}
+			if got := n.Get("shape"); got != "Mcircle" {
+				t.Errorf("shape = %q, want Mcircle", got)
+			}
+			var want []string
+			if tt.wantSink != "" {
+				want = []string{tt.wantSink}
+			}
+			if got := sinksOf(g, source); !reflect.DeepEqual(got, want) {
+				t.Errorf("sinks = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestAddApiServerSource(t *testing.T) {
+	const (
+		source = "sources.knative.dev/apiserversource/api"
+		prefix = "Source api\nApiServerSource\nsources.knative.dev"
+	)
+	tests := []struct {
+		name      string
+		sink      string
+		resources []schema.GroupVersionKind
+		sends     []string
+		want      string
+		wantSink  string
+	}{{
+		name:      "one kind",
+		sink:      "http://broker-ingress.knative-eventing.svc.cluster.local/default/default",
+		resources: []schema.GroupVersionKind{{Version: "v1", Kind: "Event"}},
+		want:      prefix + "\nwatches: Event",
+		wantSink:  "eventing.knative.dev/broker/default",
+	}, {
+		name: "kinds and event types",
+		sink: "http://broker-ingress.knative-eventing.svc.cluster.local/default/default",
+		resources: []schema.GroupVersionKind{
+			{Version: "v1", Kind: "Pod"},
+			{Group: "apps", Version: "v1", Kind: "Deployment"},
+		},
+		sends: []string{"dev.knative.apiserver.resource.add", "dev.knative.apiserver.resource.delete"},
+		want: prefix + "\nwatches: Pod\nwatches: Deployment.apps" +
+			"\nsends: dev.knative.apiserver.resource.add\nsends: dev.knative.apiserver.resource.delete",
+		wantSink: "eventing.knative.dev/broker/default",
+	}, {
+		name:      "no sink yet",
+		resources: []schema.GroupVersionKind{{Version: "v1", Kind: "Event"}},
+		want:      prefix + "\nwatches: Event",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newSource("default", "api", tt.sink)
+			api.Kind = "ApiServerSource"
+			for _, typ := range tt.sends {
+				api.Status.CloudEventAttributes = append(api.Status.CloudEventAttributes, duckv1.CloudEventAttributes{Type: typ})
+			}
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			g.AddApiServerSource(api, tt.resources)
+
+			n := g.nodes[source]
+			if got := n.Get("label"); got != tt.want {
+				t.Errorf("label = %q, want %q", got, tt.want)
+			}
+			if got := n.Get("shape"); got != "cds" {
+				t.Errorf("shape = %q, want cds", got)
+			}
+			var want []string
+			if tt.wantSink != "" {
+				want = []string{tt.wantSink}
+			}
+			if got := sinksOf(g, source); !reflect.DeepEqual(got, want) {
+				t.Errorf("sinks = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSourceSinkRef(t *testing.T) {
+	tests := []struct {
+		name     string
+		sourceNS string
+		ref      duckv1.KReference
+		want     string
+	}{{
+		name:     "no namespace",
+		sourceNS: "default",
+		ref:      duckv1.KReference{Kind: "Broker", APIVersion: "eventing.knative.dev/v1beta1", Name: "default"},
+		want:     "eventing.knative.dev/broker/default/default",
+	}, {
+		name:     "no namespace in another namespace",
+		sourceNS: "other",
+		ref:      duckv1.KReference{Kind: "Broker", APIVersion: "eventing.knative.dev/v1beta1", Name: "b"},
+		want:     "eventing.knative.dev/broker/other/b",
+	}, {
+		name:     "not in the source namespace",
+		sourceNS: "default",
+		ref:      duckv1.KReference{Kind: "Broker", APIVersion: "eventing.knative.dev/v1beta1", Name: "b"},
+	}, {
+		name:     "namespace",
+		sourceNS: "default",
+		ref:      duckv1.KReference{Kind: "Broker", APIVersion: "eventing.knative.dev/v1beta1", Namespace: "other", Name: "b"},
+		want:     "eventing.knative.dev/broker/other/b",
+	}, {
+		name:     "channel",
+		sourceNS: "default",
+		ref:      duckv1.KReference{Kind: "InMemoryChannel", APIVersion: "messaging.knative.dev/v1beta1", Name: "ch"},
+		want:     "messaging.knative.dev/inmemorychannel/default/ch",
+	}, {
+		name:     "missing",
+		sourceNS: "default",
+		ref:      duckv1.KReference{Kind: "Broker", APIVersion: "eventing.knative.dev/v1beta1", Name: "gone"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Keys name the namespace, so only the sink in the namespace
+			// defaulted to matches.
+			g := New("default", WithNamespaceClusters(true))
+			g.AddBroker(newBroker("default", "default"))
+			g.AddBroker(newBroker("other", "b"))
+			g.AddInMemoryChannel(newChannel("default", "ch"))
+			// The sink is not resolved into the status yet.
+			source := newSource(tt.sourceNS, "ping", "")
+			ref := tt.ref
+			source.Spec.Sink.Ref = &ref
+			g.AddSource(source)
+
+			var want []string
+			if tt.want != "" {
+				want = []string{tt.want}
+			}
+			if got := sinksOf(g, "sources.knative.dev/pingsource/"+tt.sourceNS+"/ping"); !reflect.DeepEqual(got, want) {
+				t.Errorf("sinks = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestAddSinkBinding(t *testing.T) {
+	const binding = "sources.knative.dev/sinkbinding/bind"
+	tests := []struct {
+		name        string
+		sink        string
+		subject     tracker.Reference
+		wantSubject string
+		wantSink    string
+	}{{
+		name:        "existing subject",
+		sink:        "http://broker-ingress.knative-eventing.svc.cluster.local/default/default",
+		subject:     tracker.Reference{APIVersion: "serving.knative.dev/v1", Kind: "Service", Name: "svc"},
+		wantSubject: "serving.knative.dev/service/svc",
+		wantSink:    "eventing.knative.dev/broker/default",
+	}, {
+		name:        "unknown subject",
+		sink:        "http://broker-ingress.knative-eventing.svc.cluster.local/default/default",
+		subject:     tracker.Reference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+		wantSubject: "apps/deployment/web",
+		wantSink:    "eventing.knative.dev/broker/default",
+	}, {
+		name: "selector",
+		subject: tracker.Reference{APIVersion: "apps/v1", Kind: "Deployment", Selector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": "web"},
+		}},
+		wantSubject: "apps/deployment/app=web",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			g.AddKnService(newKnService("default", "svc"))
+			bind := newSource("default", "bind", tt.sink)
+			bind.Kind = "SinkBinding"
+			g.AddSinkBinding(bind, tt.subject)
+
+			if got := g.nodes[binding].Get("shape"); got != "component" {
+				t.Errorf("shape = %q, want component", got)
+			}
+			var want []string
+			if tt.wantSink != "" {
+				want = []string{tt.wantSink}
+			}
+			if got := sinksOf(g, binding); !reflect.DeepEqual(got, want) {
+				t.Errorf("sinks = %v, want %v", got, want)
+			}
+			subjects := edgesOf(g, relSubject)
+			if want := []Edge{{From: binding, To: tt.wantSubject, Relationship: relSubject}}; !reflect.DeepEqual(subjects, want) {
+				t.Fatalf("subject edges = %v, want %v", subjects, want)
+			}
+			if e := findEdge(t, g, binding, tt.wantSubject); e.Get("style") != "dashed" {
+				t.Errorf("subject edge style = %q, want dashed", e.Get("style"))
+			}
+		})
+	}
+}
+
+func TestKnServiceSinkEnv(t *testing.T) {
+	const (
+		broker  = "eventing.knative.dev/broker/default"
+		channel = "messaging.knative.dev/inmemorychannel/ch"
+	)
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "broker", value: "http://broker-ingress.knative-eventing.svc.cluster.local/default/default", want: broker},
+		{name: "trailing slash", value: "http://broker-ingress.knative-eventing.svc.cluster.local/default/default/", want: broker},
+		{name: "broker port", value: "http://broker-ingress.knative-eventing.svc.cluster.local:80/default/default", want: broker},
+		{name: "channel port", value: "http://ch-kn-channel.default.svc.cluster.local:80", want: channel},
+		{name: "channel path", value: "http://ch-kn-channel.default.svc.cluster.local/events", want: channel},
+		{name: "channel port and path", value: "https://ch-kn-channel.default.svc.cluster.local:8443/events/", want: channel},
+		{name: "no scheme", value: "ch-kn-channel.default.svc.cluster.local:80", want: channel},
+		{name: "channel by name", value: "http://ch.default.svc.cluster.local", want: channel},
+		{name: "broker by name", value: "http://default-broker.default.svc.cluster.local:80/", want: broker},
+		// Values that match nothing are drawn as they are.
+		{name: "unknown", value: "http://gone.default.svc.cluster.local:80", want: "uri/http://gone.default.svc.cluster.local:80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			g.AddInMemoryChannel(newChannel("default", "ch"))
+			g.AddKnService(newKnService("default", "svc", corev1.EnvVar{Name: "K_SINK", Value: tt.value}))
+
+			if got := sinksOf(g, "serving.knative.dev/service/svc"); !reflect.DeepEqual(got, []string{tt.want}) {
+				t.Errorf("sinks = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
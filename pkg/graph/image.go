@@ -0,0 +1,47 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrGraphvizNotFound is returned when rendering an image needs the graphviz
+// dot binary and it is not on the PATH. Callers can fall back to the DOT
+// source from String.
+var ErrGraphvizNotFound = errors.New("graphviz dot binary not found on PATH")
+
+// RenderSVG lays the graph out with graphviz and returns it as SVG.
+func (g *Graph) RenderSVG(ctx context.Context) ([]byte, error) {
+	return g.renderImage(ctx, "svg")
+}
+
+// RenderPNG lays the graph out with graphviz and returns it as PNG.
+func (g *Graph) RenderPNG(ctx context.Context) ([]byte, error) {
+	return g.renderImage(ctx, "png")
+}
+
+// renderImage pipes the DOT source to graphviz dot, stopping it if ctx is
+// done, and returns the image in format.
+func (g *Graph) renderImage(ctx context.Context, format string) ([]byte, error) {
+	path, err := exec.LookPath("dot")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGraphvizNotFound, err)
+	}
+
+	var out, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "-T"+format)
+	cmd.Stdin = strings.NewReader(g.String())
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("dot -T%s: %v: %s", format, err, strings.TrimSpace(stderr.String()))
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// withPath runs f with a dot script with body first on the PATH, or with
+// PATH set to an empty directory if body is empty.
+func withPath(t *testing.T, body string, f func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "graphviz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := dir
+	if body != "" {
+		path += string(os.PathListSeparator) + os.Getenv("PATH")
+		if err := ioutil.WriteFile(filepath.Join(dir, "dot"), []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", path)
+	f()
+}
+
+func TestRenderImage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake dot binaries are shell scripts")
+	}
+	g := New("default")
+	g.AddBroker(newBroker("default", "default"))
+
+	render := map[string]func(*Graph, context.Context) ([]byte, error){
+		"svg": (*Graph).RenderSVG,
+		"png": (*Graph).RenderPNG,
+	}
+	tests := []struct {
+		name     string
+		dot      string
+		want     string
+		wantErr  string
+		notFound bool
+	}{{
+		name:     "missing dot",
+		notFound: true,
+	}, {
+		name: "pipes the DOT source",
+		dot:  `test "$1" = "-T$FORMAT" && cat`,
+		want: g.String(),
+	}, {
+		name:    "dot fails",
+		dot:     "echo syntax error >&2; exit 1",
+		wantErr: "syntax error",
+	}}
+	for _, tt := range tests {
+		for format, f := range render {
+			t.Run(tt.name+"/"+format, func(t *testing.T) {
+				os.Setenv("FORMAT", format)
+				defer os.Unsetenv("FORMAT")
+				withPath(t, tt.dot, func() {
+					got, err := f(g, context.Background())
+					if errors.Is(err, ErrGraphvizNotFound) != tt.notFound {
+						t.Errorf("error = %v, want ErrGraphvizNotFound %v", err, tt.notFound)
+					}
+					if tt.wantErr != "" {
+						if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+							t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+						}
+						return
+					}
+					if !tt.notFound && err != nil {
+						t.Fatal(err)
+					}
+					if string(got) != tt.want {
+						t.Errorf("image = %q, want %q", got, tt.want)
+					}
+				})
+			})
+		}
+	}
+}
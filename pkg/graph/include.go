@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"github.com/tmc/dot"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// IncludeOnly draws only the resources of the given kinds, with the edges
+// between them, when the graph is rendered. Versions are ignored. Nodes drawn
+// as part of an included resource, such as the steps of a Sequence, are
+// kept with it. Calling it again replaces the kinds; with none, every
+// resource is drawn.
+func (g *Graph) IncludeOnly(gvks ...schema.GroupVersionKind) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.includeOnly = nil
+	for _, gvk := range gvks {
+		g.includeOnly = append(g.includeOnly, gvk.GroupKind())
+	}
+}
+
+// included returns a copy of the graph with only the resources of the kinds
+// set by IncludeOnly.
+func (g *Graph) included() *Graph {
+	keep := make(map[*dot.Node]bool)
+	resources := make(map[*dot.Node]bool)
+	clusters := make(map[*dot.SubGraph]bool)
+	for k, n := range g.nodes {
+		if _, ok := g.info[k]; ok {
+			resources[n] = true
+		}
+		if g.isIncluded(k) {
+			keep[n] = true
+			if sg, ok := g.parent[n]; ok {
+				clusters[sg] = true
+			}
+		}
+	}
+	for _, n := range g.order {
+		if !resources[n] && clusters[g.parent[n]] {
+			keep[n] = true
+		}
+	}
+
+	f := g.subset(keep)
+	f.includeOnly = nil
+	return f
+}
+
+// isIncluded returns whether the resource tracked under key is of a kind set
+// by IncludeOnly. Resources added without their kind set are matched by key.
+func (g *Graph) isIncluded(key string) bool {
+	info, ok := g.info[key]
+	if !ok {
+		return false
+	}
+	group := schema.FromAPIVersionAndKind(info.apiVersion, info.kind).Group
+	for _, gk := range g.includeOnly {
+		if info.kind == gk.Kind && group == gk.Group {
+			return true
+		}
+		if key == g.resourceKey(gk.Group, gk.Kind, info.namespace, info.name) {
+			return true
+		}
+	}
+	return false
+}
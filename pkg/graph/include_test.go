@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIncludeOnly(t *testing.T) {
+	const (
+		broker   = `"Broker http://broker-ingress.knative-eventing.svc.cluster.local/default/default"`
+		trigger  = `"Trigger t"`
+		service  = `"svc\nService\nserving.knative.dev"`
+		source   = `"Source ping\nPingSource\nsources.knative.dev"`
+		sequence = `"Sequence http://seq-kn-sequence-0-kn-channel.default.svc.cluster.local"`
+		step     = `"flows.knative.dev/sequencestep/seq-0"`
+	)
+	all := []string{broker, trigger, service, source, sequence, step}
+	tests := []struct {
+		name  string
+		gvks  []schema.GroupVersionKind
+		drawn []string
+	}{{
+		name:  "everything",
+		drawn: all,
+	}, {
+		name: "brokers and triggers",
+		gvks: []schema.GroupVersionKind{
+			{Group: "eventing.knative.dev", Kind: "Broker"},
+			// Versions are ignored.
+			{Group: "eventing.knative.dev", Version: "v1", Kind: "Trigger"},
+		},
+		drawn: []string{broker, trigger, broker + " -> " + trigger},
+	}, {
+		name:  "sequence keeps its steps",
+		gvks:  []schema.GroupVersionKind{{Group: "flows.knative.dev", Kind: "Sequence"}},
+		drawn: []string{sequence, step},
+	}, {
+		name: "wrong group",
+		gvks: []schema.GroupVersionKind{{Group: "messaging.knative.dev", Kind: "Broker"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+			g.AddKnService(newKnService("default", "svc"))
+			g.AddSource(newSource("default", "ping", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"))
+			g.AddSequence(newSequence("default", "seq", "first"))
+			g.IncludeOnly(tt.gvks...)
+
+			out := g.String()
+			drawn := make(map[string]bool)
+			for _, want := range tt.drawn {
+				drawn[want] = true
+				if !strings.Contains(out, want) {
+					t.Errorf("String() does not draw %s:\n%s", want, out)
+				}
+			}
+			for _, skipped := range all {
+				if !drawn[skipped] && strings.Contains(out, skipped+" [") {
+					t.Errorf("String() draws %s:\n%s", skipped, out)
+				}
+			}
+
+			// The graph itself is left whole.
+			g.IncludeOnly()
+			out = g.String()
+			for _, want := range all {
+				if !strings.Contains(out, want) {
+					t.Errorf("after IncludeOnly(), String() does not draw %s", want)
+				}
+			}
+		})
+	}
+}
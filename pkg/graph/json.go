@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// jsonGraph is the model MarshalJSON writes, for UIs that lay the graph out
+// themselves.
+type jsonGraph struct {
+	Nodes []jsonNode `json:"nodes"`
+	Edges []jsonEdge `json:"edges"`
+}
+
+type jsonNode struct {
+	Key        string `json:"key"`
+	Label      string `json:"label"`
+	Shape      string `json:"shape"`
+	Kind       string `json:"kind,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+}
+
+type jsonEdge struct {
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	Relationship string `json:"relationship,omitempty"`
+	Direction    string `json:"direction"`
+	Color        string `json:"color,omitempty"`
+}
+
+// MarshalJSON returns the tracked nodes and drawn edges as JSON, identified
+// by the same keys the graph tracks resources under. Nodes are sorted by key
+// and edges by source, target and relationship.
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	out := jsonGraph{
+		Nodes: make([]jsonNode, 0, len(g.nodes)),
+		Edges: make([]jsonEdge, 0, len(g.edges)),
+	}
+	for k, n := range g.nodes {
+		label := n.Get("label")
+		if label == "" {
+			label = n.Name()
+		}
+		shape := n.Get("shape")
+		if shape == "" {
+			// Graphviz default.
+			shape = "ellipse"
+		}
+		info := g.info[k]
+		out.Nodes = append(out.Nodes, jsonNode{
+			Key:        k,
+			Label:      unescapeLabel(label),
+			Shape:      shape,
+			Kind:       info.kind,
+			APIVersion: info.apiVersion,
+		})
+	}
+	sort.Slice(out.Nodes, func(i, j int) bool { return out.Nodes[i].Key < out.Nodes[j].Key })
+
+	for _, e := range g.edges {
+		edge := g.edgeFor(e)
+		dir := e.Get("dir")
+		if dir == "" {
+			// Graphviz default for digraphs.
+			dir = "forward"
+		}
+		out.Edges = append(out.Edges, jsonEdge{
+			Source:       edge.From,
+			Target:       edge.To,
+			Relationship: edge.Relationship,
+			Direction:    dir,
+			Color:        e.Get("color"),
+		})
+	}
+	sort.SliceStable(out.Edges, func(i, j int) bool {
+		a, b := out.Edges[i], out.Edges[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		return a.Relationship < b.Relationship
+	})
+	return json.Marshal(out)
+}
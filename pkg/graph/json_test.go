@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	want := jsonGraph{
+		Nodes: []jsonNode{
+			{Key: "eventing.knative.dev/broker/default", Label: "Ingress", Shape: "oval", Kind: "Broker", APIVersion: "eventing.knative.dev/v1beta1"},
+			{Key: "eventing.knative.dev/trigger/a", Label: "Trigger a", Shape: "box", Kind: "Trigger", APIVersion: "eventing.knative.dev/v1beta1"},
+			{Key: "eventing.knative.dev/trigger/b", Label: "Trigger b", Shape: "box", Kind: "Trigger", APIVersion: "eventing.knative.dev/v1beta1"},
+			{Key: "serving.knative.dev/service/svc", Label: "svc\nService\nserving.knative.dev", Shape: "septagon", Kind: "Service", APIVersion: "serving.knative.dev/v1"},
+		},
+		Edges: []jsonEdge{
+			{Source: "eventing.knative.dev/broker/default", Target: "eventing.knative.dev/trigger/a", Relationship: relTrigger, Direction: "forward", Color: "purple"},
+			{Source: "eventing.knative.dev/broker/default", Target: "eventing.knative.dev/trigger/b", Relationship: relTrigger, Direction: "forward", Color: "purple"},
+			{Source: "eventing.knative.dev/trigger/a", Target: "serving.knative.dev/service/svc", Relationship: relSubscriber, Direction: "both", Color: "purple"},
+			{Source: "eventing.knative.dev/trigger/b", Target: "serving.knative.dev/service/svc", Relationship: relSubscriber, Direction: "both", Color: "purple"},
+		},
+	}
+	tests := []struct {
+		name     string
+		triggers []string
+	}{
+		{name: "in order", triggers: []string{"a", "b"}},
+		{name: "reverse order", triggers: []string{"b", "a"}},
+	}
+	var first []byte
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			for _, name := range tt.triggers {
+				g.AddTrigger(newTrigger("default", name, "default", *serviceRef("svc")))
+			}
+			b, err := json.Marshal(g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got jsonGraph
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("MarshalJSON() =\n%s\nwant\n%+v", b, want)
+			}
+			if first == nil {
+				first = b
+			} else if string(b) != string(first) {
+				t.Errorf("MarshalJSON() depends on the order added:\n%s\n%s", first, b)
+			}
+		})
+	}
+}
+
+func TestMarshalJSONRawLabels(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "default"))
+	tr := newTrigger("default", "t", "default", duckv1.Destination{URI: mustURL("http://example.com/a&b")})
+	tr.Spec.Filter = &eventingv1beta1.TriggerFilter{Attributes: map[string]string{"type": `"back\slash"`}}
+	g.AddTrigger(tr)
+
+	b, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got jsonGraph
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"eventing.knative.dev/trigger/t": "Trigger t\ntype: \"back\\slash\"",
+		"uri/http://example.com/a&b":     "http://example.com/a&b",
+	}
+	for _, n := range got.Nodes {
+		if w, ok := want[n.Key]; ok && n.Label != w {
+			t.Errorf("node %s is labeled %q, want %q", n.Key, n.Label, w)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"github.com/tmc/dot"
+)
+
+// legendEdgeAttributes are the edge attributes that tell relationships
+// apart, copied onto the legend samples.
+var legendEdgeAttributes = []string{"arrowhead", "arrowtail", "color", "dir", "fontcolor", "penwidth", "style"}
+
+// legendName is the name of the legend cluster.
+const legendName = "cluster_legend"
+
+// AddLegend adds a cluster with a sample edge for each relationship drawn so
+// far, styled like the first edge drawn for it. Call it once the graph is
+// complete; calling it again replaces the legend added before.
+func (g *Graph) AddLegend() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.dropLegend()
+
+	samples := make(map[string]*edge)
+	var rels []string
+	for _, e := range g.edges {
+		if e.rel == "" {
+			continue
+		}
+		if _, ok := samples[e.rel]; !ok {
+			samples[e.rel] = e
+			rels = append(rels, e.rel)
+		}
+	}
+	if len(rels) == 0 {
+		return
+	}
+
+	lg := dot.NewSubgraph(legendName)
+	_ = lg.Set("label", "Legend")
+	g.addCluster(nil, lg)
+	for _, rel := range rels {
+		from := dot.NewNode("legend " + rel)
+		_ = from.Set("shape", "point")
+		to := dot.NewNode("legend " + rel + " to")
+		_ = to.Set("shape", "point")
+		g.addNodeTo(lg, from)
+		g.addNodeTo(lg, to)
+
+		e := dot.NewEdge(from, to)
+		for _, attr := range legendEdgeAttributes {
+			if v := samples[rel].Get(attr); v != "" {
+				_ = e.Set(attr, v)
+			}
+		}
+		_ = e.Set("label", rel)
+		g.legend = append(g.legend, e)
+		g.Graph.AddEdge(e)
+	}
+}
+
+// dropLegend removes the legend cluster, its nodes and sample edges, if a
+// legend was added.
+func (g *Graph) dropLegend() {
+	var lg *dot.SubGraph
+	for _, sg := range g.clusters {
+		if sg.Name() == legendName {
+			lg = sg
+		}
+	}
+	if lg == nil {
+		return
+	}
+	order := g.order[:0]
+	for _, n := range g.order {
+		if g.parent[n] == lg {
+			delete(g.parent, n)
+			continue
+		}
+		order = append(order, n)
+	}
+	g.order = order
+	g.legend = nil
+	g.dropCluster(lg)
+	g.rebuild()
+}
@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
+)
+
+func TestAddLegend(t *testing.T) {
+	tests := []struct {
+		name       string
+		deadLetter bool
+		want       []string
+	}{{
+		name:       "dead letter sink",
+		deadLetter: true,
+		want:       []string{relDeadLetter, relTrigger, relSubscriber},
+	}, {
+		name: "no dead letter sink",
+		want: []string{relTrigger, relSubscriber},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			broker := newBroker("default", "default")
+			if tt.deadLetter {
+				broker.Spec.Delivery = &eventingduckv1beta1.DeliverySpec{DeadLetterSink: serviceRef("dlq")}
+			}
+			g.AddKnService(newKnService("default", "dlq"))
+			g.AddBroker(broker)
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+			g.AddLegend()
+			// Adding it again replaces the first legend.
+			g.AddLegend()
+
+			var got []string
+			for _, e := range g.legend {
+				got = append(got, e.Get("label"))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("legend samples = %q, want %q", got, tt.want)
+			}
+			for _, e := range g.legend {
+				if e.Get("label") != relDeadLetter {
+					continue
+				}
+				for attr, want := range map[string]string{"style": "dashed", "color": "red"} {
+					if got := e.Get(attr); got != want {
+						t.Errorf("dead letter sample %s = %q, want %q", attr, got, want)
+					}
+				}
+			}
+			if got := strings.Count(g.String(), legendName); got != 1 {
+				t.Errorf("rendered %d legend clusters, want 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package graph
+
+import (
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	flowsv1beta1 "knative.dev/eventing/pkg/apis/flows/v1beta1"
+	messagingv1beta1 "knative.dev/eventing/pkg/apis/messaging/v1beta1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+// Listers list the resources of each kind to graph, for example from an
+// informer cache. Kinds without a lister are left out.
+type Listers struct {
+	Brokers          func() ([]*eventingv1beta1.Broker, error)
+	EventTypes       func() ([]*eventingv1beta1.EventType, error)
+	Triggers         func() ([]*eventingv1beta1.Trigger, error)
+	Channels         func() ([]*messagingv1beta1.Channel, error)
+	InMemoryChannels func() ([]*messagingv1beta1.InMemoryChannel, error)
+	Subscriptions    func() ([]*messagingv1beta1.Subscription, error)
+	Services         func() ([]*servingv1.Service, error)
+	Sequences        func() ([]*flowsv1beta1.Sequence, error)
+	Parallels        func() ([]*flowsv1beta1.Parallel, error)
+	Sources          func() ([]*duckv1.Source, error)
+}
+
+// FromLister builds the graph for ns from the resources listed by l. They are
+// added in the order ForTriggers adds them, with the kinds it leaves out
+// fitted in: channels before the triggers that may deliver to them,
+// subscriptions after the triggers and parallels after the sequences. It
+// returns the first listing error.
+func FromLister(ns string, l Listers, opts ...Option) (*Graph, error) {
+	g := New(ns, opts...)
+
+	var services []*servingv1.Service
+	if l.Services != nil {
+		var err error
+		if services, err = l.Services(); err != nil {
+			return nil, err
+		}
+		// Pre-load the services so sinks and subscribers resolve to them.
+		for _, service := range services {
+			g.LoadKnService(*service)
+		}
+	}
+	if l.Brokers != nil {
+		brokers, err := l.Brokers()
+		if err != nil {
+			return nil, err
+		}
+		for _, broker := range brokers {
+			g.AddBroker(*broker)
+		}
+	}
+	if l.Channels != nil {
+		channels, err := l.Channels()
+		if err != nil {
+			return nil, err
+		}
+		for _, channel := range channels {
+			g.AddChannel(*channel)
+		}
+	}
+	if l.InMemoryChannels != nil {
+		channels, err := l.InMemoryChannels()
+		if err != nil {
+			return nil, err
+		}
+		for _, channel := range channels {
+			g.AddInMemoryChannel(*channel)
+		}
+	}
+	if l.EventTypes != nil {
+		eventTypes, err := l.EventTypes()
+		if err != nil {
+			return nil, err
+		}
+		for _, et := range eventTypes {
+			g.AddEventType(*et)
+		}
+	}
+	if l.Triggers != nil {
+		triggers, err := l.Triggers()
+		if err != nil {
+			return nil, err
+		}
+		for _, trigger := range triggers {
+			g.AddTrigger(*trigger)
+		}
+	}
+	if l.Subscriptions != nil {
+		subscriptions, err := l.Subscriptions()
+		if err != nil {
+			return nil, err
+		}
+		for _, subscription := range subscriptions {
+			g.AddSubscription(*subscription)
+		}
+	}
+	for _, service := range services {
+		g.AddKnService(*service)
+	}
+	if l.Sequences != nil {
+		sequences, err := l.Sequences()
+		if err != nil {
+			return nil, err
+		}
+		for _, sequence := range sequences {
+			g.AddSequence(*sequence)
+		}
+	}
+	if l.Parallels != nil {
+		parallels, err := l.Parallels()
+		if err != nil {
+			return nil, err
+		}
+		for _, parallel := range parallels {
+			g.AddParallel(*parallel)
+		}
+	}
+	if l.Sources != nil {
+		sources, err := l.Sources()
+		if err != nil {
+			return nil, err
+		}
+		for _, source := range sources {
+			g.AddSource(*source)
+		}
+	}
+	return g, nil
+}
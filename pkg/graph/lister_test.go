@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"errors"
+	"testing"
+
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+func TestFromLister(t *testing.T) {
+	broker := newBroker("default", "default")
+	trigger := newTrigger("default", "t", "default", *serviceRef("svc"))
+	service := newKnService("default", "svc")
+	brokers := func() ([]*eventingv1beta1.Broker, error) {
+		return []*eventingv1beta1.Broker{&broker}, nil
+	}
+	triggers := func() ([]*eventingv1beta1.Trigger, error) {
+		return []*eventingv1beta1.Trigger{&trigger}, nil
+	}
+	errList := errors.New("list failed")
+
+	tests := []struct {
+		name      string
+		listers   Listers
+		want      []Edge
+		wantCalls int
+		wantErr   error
+	}{{
+		name:    "broker and trigger",
+		listers: Listers{Brokers: brokers, Triggers: triggers},
+		want: []Edge{
+			{From: "eventing.knative.dev/broker/default", To: "eventing.knative.dev/trigger/t", Relationship: relTrigger},
+			{From: "eventing.knative.dev/trigger/t", To: "serving.knative.dev/service/svc", Relationship: relSubscriber},
+		},
+	}, {
+		name:    "with services",
+		listers: Listers{Brokers: brokers, Triggers: triggers},
+		want: []Edge{
+			{From: "eventing.knative.dev/broker/default", To: "eventing.knative.dev/trigger/t", Relationship: relTrigger},
+			{From: "eventing.knative.dev/trigger/t", To: "serving.knative.dev/service/svc", Relationship: relSubscriber},
+		},
+		wantCalls: 1,
+	}, {
+		name: "listing error",
+		listers: Listers{Brokers: brokers, Triggers: func() ([]*eventingv1beta1.Trigger, error) {
+			return nil, errList
+		}},
+		wantErr: errList,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			if tt.wantCalls > 0 {
+				tt.listers.Services = func() ([]*servingv1.Service, error) {
+					calls++
+					return []*servingv1.Service{&service}, nil
+				}
+			}
+
+			g, err := FromLister("default", tt.listers)
+			if err != tt.wantErr {
+				t.Fatalf("FromLister() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("services listed %d times, want %d", calls, tt.wantCalls)
+			}
+			var got []Edge
+			for _, e := range g.edges {
+				got = append(got, g.edgeFor(e))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got edges %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("edge %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the event flow as a nested bullet list, with one top
+// level entry per broker or channel and the resources events reach from it
+// nested below. Brokers and channels reached from another one are listed but
+// not expanded again.
+func (g *Graph) Markdown() string {
+	adj := g.adjacency()
+	b := &strings.Builder{}
+	for _, n := range g.order {
+		key := g.keyOf(n)
+		if !g.isIngress(key) {
+			continue
+		}
+		fmt.Fprintf(b, "- %s\n", g.markdownName(key))
+		g.markdownChildren(b, key, adj, 1, map[string]bool{key: true})
+	}
+	return b.String()
+}
+
+func (g *Graph) markdownChildren(b *strings.Builder, key string, adj map[string][]string, depth int, seen map[string]bool) {
+	for _, next := range adj[key] {
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		fmt.Fprintf(b, "%s- %s\n", strings.Repeat("  ", depth), g.markdownName(next))
+		if !g.isIngress(next) {
+			g.markdownChildren(b, next, adj, depth+1, seen)
+		}
+	}
+}
+
+// markdownName names the node with key by the resource it was drawn for.
+func (g *Graph) markdownName(key string) string {
+	if info, ok := g.info[key]; ok {
+		return fmt.Sprintf("%s %s", info.kind, info.name)
+	}
+	return key
+}
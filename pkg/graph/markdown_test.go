@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(g *Graph)
+		want  string
+	}{{
+		name: "trigger under its broker",
+		build: func(g *Graph) {
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+		},
+		want: "- Broker default\n" +
+			"  - Trigger t\n" +
+			"    - Service svc\n",
+	}, {
+		name: "channel reached from a broker",
+		build: func(g *Graph) {
+			g.AddBroker(newBroker("default", "default"))
+			g.AddInMemoryChannel(newChannel("default", "ch"))
+			g.AddTrigger(newTrigger("default", "t", "default", *channelRef("ch")))
+		},
+		want: "- Broker default\n" +
+			"  - Trigger t\n" +
+			"    - InMemoryChannel ch\n" +
+			"- InMemoryChannel ch\n",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			tt.build(g)
+			if got := g.Markdown(); got != tt.want {
+				t.Errorf("Markdown() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tmc/dot"
+)
+
+// mermaidEscapes replaces the characters Mermaid reads as syntax inside
+// quoted node and edge text with entity codes.
+var mermaidEscapes = strings.NewReplacer(
+	"#", "#35;",
+	`"`, "#quot;",
+	"<", "#lt;",
+	">", "#gt;",
+	"|", "#124;",
+	"\n", "<br/>",
+)
+
+// ToMermaid renders the graph as a Mermaid flowchart, for docs that render
+// Mermaid but not graphviz. Node ids are the node keys with characters
+// Mermaid does not take in ids replaced, clusters become subgraphs and the
+// legend is left out.
+func (g *Graph) ToMermaid() string {
+	legend := make(map[*dot.Node]bool)
+	for _, e := range g.legend {
+		legend[e.Source()], legend[e.Destination()] = true, true
+	}
+
+	ids := make(map[*dot.Node]string, len(g.order))
+	used := make(map[string]bool, len(g.order))
+	nodes := make(map[*dot.SubGraph][]*dot.Node)
+	held := make(map[*dot.SubGraph]bool)
+	for _, n := range g.order {
+		if legend[n] {
+			continue
+		}
+		id := mermaidID(g.keyOf(n))
+		for i := 2; used[id]; i++ {
+			id = fmt.Sprintf("%s_%d", mermaidID(g.keyOf(n)), i)
+		}
+		used[id] = true
+		ids[n] = id
+
+		sg := g.parent[n]
+		nodes[sg] = append(nodes[sg], n)
+		for ; sg != nil; sg = g.clusterParent[sg] {
+			held[sg] = true
+		}
+	}
+	children := make(map[*dot.SubGraph][]*dot.SubGraph)
+	for _, sg := range g.clusters {
+		if held[sg] {
+			children[g.clusterParent[sg]] = append(children[g.clusterParent[sg]], sg)
+		}
+	}
+
+	b := &strings.Builder{}
+	b.WriteString("flowchart LR\n")
+	var write func(sg *dot.SubGraph, depth int)
+	write = func(sg *dot.SubGraph, depth int) {
+		indent := strings.Repeat("  ", depth)
+		for _, c := range children[sg] {
+			fmt.Fprintf(b, "%ssubgraph %s [\"%s\"]\n", indent, mermaidID(c.Name()), mermaidText(c.Get("label")))
+			write(c, depth+1)
+			fmt.Fprintf(b, "%send\n", indent)
+		}
+		for _, n := range nodes[sg] {
+			label := n.Get("label")
+			if label == "" {
+				label = n.Name()
+			}
+			fmt.Fprintf(b, "%s%s[\"%s\"]\n", indent, ids[n], mermaidText(label))
+		}
+	}
+	write(nil, 1)
+
+	for _, e := range g.edges {
+		src, dst := ids[e.Source()], ids[e.Destination()]
+		arrow := "-->"
+		switch e.Get("dir") {
+		case "both", "none":
+			arrow = "---"
+		case "back":
+			src, dst = dst, src
+		}
+		if label := e.Get("label"); label != "" {
+			arrow = fmt.Sprintf("%s|\"%s\"|", arrow, mermaidText(label))
+		}
+		fmt.Fprintf(b, "  %s %s %s\n", src, arrow, dst)
+	}
+	return b.String()
+}
+
+// mermaidText returns the DOT label s as Mermaid text.
+func mermaidText(s string) string {
+	return mermaidEscapes.Replace(unescapeLabel(s))
+}
+
+// mermaidID returns s with the characters Mermaid does not take in ids
+// replaced by underscores.
+func mermaidID(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
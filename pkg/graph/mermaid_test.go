@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+)
+
+func TestToMermaid(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "default"))
+	g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+	g.AddSource(newSource("default", "ping", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"))
+	g.AddLegend()
+
+	want := `flowchart LR
+  subgraph cluster_0 ["Broker default<br/>http://broker-ingress.knative-eventing.svc.cluster.local/default/default"]
+    eventing_knative_dev_broker_default["Ingress"]
+    eventing_knative_dev_trigger_t["Trigger t"]
+  end
+  serving_knative_dev_service_svc["svc<br/>Service<br/>serving.knative.dev"]
+  sources_knative_dev_pingsource_ping["Source ping<br/>PingSource<br/>sources.knative.dev"]
+  eventing_knative_dev_broker_default --> eventing_knative_dev_trigger_t
+  eventing_knative_dev_trigger_t --- serving_knative_dev_service_svc
+  sources_knative_dev_pingsource_ping --> eventing_knative_dev_broker_default
+`
+	if got := g.ToMermaid(); got != want {
+		t.Errorf("ToMermaid() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestToMermaidEscapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		label string
+		want  string
+	}{
+		{name: "quotes", label: `say "hi"`, want: `["say #quot;hi#quot;"]`},
+		{name: "brackets", label: "<b>", want: `["#lt;b#gt;"]`},
+		{name: "pipe", label: "a|b", want: `["a#124;b"]`},
+		{name: "hash", label: "#1", want: `["#35;1"]`},
+		{name: "newline", label: "a\nb", want: `["a<br/>b"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			_ = g.nodes["eventing.knative.dev/broker/default"].Set("label", tt.label)
+
+			got := g.ToMermaid()
+			if !strings.Contains(got, "eventing_knative_dev_broker_default"+tt.want+"\n") {
+				t.Errorf("ToMermaid() does not draw the broker as %s:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestToMermaidRawLabels(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "default"))
+	tr := newTrigger("default", "t", "default", *serviceRef("svc"))
+	tr.Spec.Filter = &eventingv1beta1.TriggerFilter{Attributes: map[string]string{"type": `a&"b"`}}
+	g.AddTrigger(tr)
+
+	want := `eventing_knative_dev_trigger_t["Trigger t<br/>type: a&#quot;b#quot;"]`
+	if got := g.ToMermaid(); !strings.Contains(got, want) {
+		t.Errorf("ToMermaid() does not draw the trigger as %s:\n%s", want, got)
+	}
+}
@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	flowsv1beta1 "knative.dev/eventing/pkg/apis/flows/v1beta1"
+	messagingv1beta1 "knative.dev/eventing/pkg/apis/messaging/v1beta1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+// Add dispatches obj to the matching Add* method. It returns false if the
+// kind of obj is not understood by the graph.
+func (g *Graph) Add(obj runtime.Object) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.add(obj)
+}
+
+func (g *Graph) add(obj runtime.Object) bool {
+	switch o := obj.(type) {
+	case *eventingv1beta1.Broker:
+		g.addBroker(*o)
+	case *eventingv1beta1.Trigger:
+		g.addTrigger(*o)
+	case *eventingv1beta1.EventType:
+		g.addEventType(*o)
+	case *messagingv1beta1.Channel:
+		g.addChannel(*o)
+	case *messagingv1beta1.InMemoryChannel:
+		g.addInMemoryChannel(*o)
+	case *messagingv1beta1.Subscription:
+		g.addSubscription(*o)
+	case *flowsv1beta1.Sequence:
+		g.addSequence(*o)
+	case *flowsv1beta1.Parallel:
+		g.addParallel(*o)
+	case *servingv1.Service:
+		g.addKnService(*o)
+	case *duckv1.Source:
+		g.addSource(*o)
+	default:
+		return false
+	}
+	return true
+}
+
+// objectKey returns the node key the Add* method for obj registers.
+func (g *Graph) objectKey(obj runtime.Object) string {
+	switch o := obj.(type) {
+	case *eventingv1beta1.Broker:
+		return g.brokerKey(o.Namespace, o.Name)
+	case *eventingv1beta1.Trigger:
+		return g.triggerKey(o.Namespace, o.Name)
+	case *eventingv1beta1.EventType:
+		return g.eventTypeKey(o.Namespace, o.Name)
+	case *messagingv1beta1.Channel:
+		return g.channelKey(o.Namespace, o.Name)
+	case *messagingv1beta1.InMemoryChannel:
+		return g.inMemoryChannelKey(o.Namespace, o.Name)
+	case *messagingv1beta1.Subscription:
+		return g.subscriptionKey(o.Namespace, o.Name)
+	case *flowsv1beta1.Sequence:
+		return g.sequenceKey(o.Namespace, o.Name)
+	case *flowsv1beta1.Parallel:
+		return g.parallelKey(o.Namespace, o.Name)
+	case *servingv1.Service:
+		service := *o
+		defaultServiceType(&service)
+		return g.servingKey(service.Kind, service.Namespace, service.Name)
+	case *duckv1.Source:
+		return g.gvkKey(o.GroupVersionKind(), o.Namespace, o.Name)
+	}
+	return ""
+}
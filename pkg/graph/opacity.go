@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// namedFills are the RGB values of the named fill colors the graph draws
+// with. graphviz only takes an alpha channel on colors given in hex.
+var namedFills = map[string][3]uint8{
+	"white":     {255, 255, 255},
+	"black":     {0, 0, 0},
+	"gray95":    {242, 242, 242},
+	"gray80":    {204, 204, 204},
+	"lightgray": {211, 211, 211},
+	"lightgrey": {211, 211, 211},
+}
+
+// fill is the fill color of a node before and after opacity was applied to
+// it by the last render.
+type fill struct {
+	base, applied string
+}
+
+// SetOpacity sets the fill opacity of the node with key, from 0 for clear to
+// 1 for opaque, overriding the default from WithDefaultOpacity. It returns
+// false if there is no node with that key.
+func (g *Graph) SetOpacity(key string, opacity float64) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	n, ok := g.nodes[key]
+	if !ok {
+		return false
+	}
+	g.opacity[n] = opacity
+	return true
+}
+
+// applyOpacity adds the opacity of each node to its fill color, replacing
+// the opacity applied by an earlier render. Nodes whose fill color is not
+// known as RGB stay opaque.
+func (g *Graph) applyOpacity() {
+	for _, n := range g.order {
+		opacity, ok := g.opacity[n]
+		if !ok {
+			opacity = g.defaultOpacity
+		}
+		f, ok := g.fills[n]
+		if current := n.Get("fillcolor"); !ok || current != f.applied {
+			// New node, or its fill was changed since the last render.
+			f = fill{base: current, applied: current}
+		}
+		if f.base == "" {
+			continue
+		}
+		f.applied = f.base
+		if rgb, ok := fillRGB(f.base); ok && opacity < 1 {
+			alpha := uint8(math.Round(math.Max(opacity, 0) * 255))
+			f.applied = fmt.Sprintf("#%02x%02x%02x%02x", rgb[0], rgb[1], rgb[2], alpha)
+		}
+		_ = n.Set("fillcolor", f.applied)
+		g.fills[n] = f
+	}
+}
+
+// fillRGB returns the RGB value of a named or "#rrggbb" color.
+func fillRGB(color string) ([3]uint8, bool) {
+	if rgb, ok := namedFills[strings.ToLower(color)]; ok {
+		return rgb, true
+	}
+	if len(color) != len("#rrggbb") || color[0] != '#' {
+		return [3]uint8{}, false
+	}
+	v, err := strconv.ParseUint(color[1:], 16, 32)
+	if err != nil {
+		return [3]uint8{}, false
+	}
+	return [3]uint8{uint8(v >> 16), uint8(v >> 8), uint8(v)}, true
+}
@@ -0,0 +1,57 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOpacity(t *testing.T) {
+	const key = "eventing.knative.dev/broker/default"
+	tests := []struct {
+		name    string
+		opts    []Option
+		set     map[string]float64
+		want    string
+		wantSet bool
+	}{{
+		name: "opaque",
+		want: "white",
+	}, {
+		name: "default opacity",
+		opts: []Option{WithDefaultOpacity(0.5)},
+		want: `"#ffffff80"`,
+	}, {
+		name:    "node opacity",
+		set:     map[string]float64{key: 0.25},
+		want:    `"#ffffff40"`,
+		wantSet: true,
+	}, {
+		name:    "node opacity over the default",
+		opts:    []Option{WithDefaultOpacity(0.5)},
+		set:     map[string]float64{key: 1},
+		want:    "white",
+		wantSet: true,
+	}, {
+		name: "missing node",
+		opts: []Option{WithDefaultOpacity(0)},
+		set:  map[string]float64{"eventing.knative.dev/broker/gone": 1},
+		want: `"#ffffff00"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", tt.opts...)
+			g.AddBroker(newBroker("default", "default"))
+			for k, opacity := range tt.set {
+				if got := g.SetOpacity(k, opacity); got != tt.wantSet {
+					t.Errorf("SetOpacity(%q) = %v, want %v", k, got, tt.wantSet)
+				}
+			}
+			// Rendering twice must not apply the opacity twice.
+			_ = g.String()
+			out := g.String()
+			if !strings.Contains(out, "fillcolor="+tt.want+",") {
+				t.Errorf("broker is not drawn with fillcolor=%s:\n%s", tt.want, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,396 @@
+package graph
+
+import (
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Option configures optional rendering behavior of a Graph.
+type Option func(*Graph)
+
+// LatencyAnnotation is the Trigger annotation holding the observed delivery
+// latency to its subscriber, rendered when WithLatencyLabels is enabled.
+const LatencyAnnotation = "graph.n3wscott.com/latency"
+
+// ConcurrencyAnnotation is the Trigger annotation holding how many events are
+// delivered to its subscriber at once, rendered when WithConcurrencyLabels is
+// enabled.
+const ConcurrencyAnnotation = "graph.n3wscott.com/concurrency"
+
+// WithConcurrencyLabels appends the ConcurrencyAnnotation value of a Trigger
+// to the label of its subscriber edge.
+func WithConcurrencyLabels(enabled bool) Option {
+	return func(g *Graph) {
+		g.concurrencyLabels = enabled
+	}
+}
+
+// AudienceAnnotation is the Trigger or Subscription annotation holding the
+// OIDC audience its subscriber authenticates deliveries with, rendered when
+// WithAuthInfo is enabled. The vendored Destination has no audience field
+// yet, so it is read from here.
+const AudienceAnnotation = "graph.n3wscott.com/audience"
+
+// WithAuthInfo appends the AudienceAnnotation value of a Trigger or
+// Subscription to the tooltip of its subscriber edge, for security review.
+func WithAuthInfo(enabled bool) Option {
+	return func(g *Graph) {
+		g.authInfo = enabled
+	}
+}
+
+// KeyFunc returns the key a resource is tracked under. Only the group and
+// kind of gvk are set.
+type KeyFunc func(gvk schema.GroupVersionKind, namespace, name string) string
+
+// PartOfLabel is the label naming the application a resource is part of,
+// used by WithPartOfGrouping.
+const PartOfLabel = "app.kubernetes.io/part-of"
+
+// WithLatencyLabels appends the LatencyAnnotation value of a Trigger to the
+// label of its subscriber edge.
+func WithLatencyLabels(enabled bool) Option {
+	return func(g *Graph) {
+		g.latencyLabels = enabled
+	}
+}
+
+// WithClusterLabel replaces the label rendered on broker, channel and
+// sequence clusters. The default renders "<kind> <name>\n<dns>".
+func WithClusterLabel(label func(kind, name, dns string) string) Option {
+	return func(g *Graph) {
+		if label != nil {
+			g.clusterLabel = label
+		}
+	}
+}
+
+// WithIngressPorts attaches the edges leaving a broker ingress node at
+// distinct compass points, which reduces crossings in dense broker clusters.
+func WithIngressPorts(enabled bool) Option {
+	return func(g *Graph) {
+		g.ingressPorts = enabled
+	}
+}
+
+// WithGroupSourcesBySink clusters sources together with the other sources
+// that sink to the same address.
+func WithGroupSourcesBySink(enabled bool) Option {
+	return func(g *Graph) {
+		g.groupSourcesBySink = enabled
+	}
+}
+
+// WithTooltips sets the tooltip of sink edges to describe the direction the
+// events flow in, e.g. "events flow from my-source to default".
+func WithTooltips(enabled bool) Option {
+	return func(g *Graph) {
+		g.tooltips = enabled
+	}
+}
+
+// WithMaxNodes stops adding resources to the graph once it tracks n nodes,
+// recording a warning instead. Zero or less means no limit.
+func WithMaxNodes(n int) Option {
+	return func(g *Graph) {
+		g.maxNodes = n
+	}
+}
+
+// WithReadinessPalette sets the colors of resources that are ready, not
+// ready and of unknown readiness, in place of black, deeppink and
+// darkorange2. Blank colors keep their default.
+func WithReadinessPalette(ready, notReady, unknown string) Option {
+	return func(g *Graph) {
+		for _, c := range []struct {
+			color string
+			to    *string
+		}{
+			{ready, &g.readinessPalette.ready},
+			{notReady, &g.readinessPalette.notReady},
+			{unknown, &g.readinessPalette.unknown},
+		} {
+			if strings.TrimSpace(c.color) != "" {
+				*c.to = c.color
+			}
+		}
+	}
+}
+
+// WithDeletionState renders resources that are being deleted, those with a
+// deletion timestamp, with a red dashed outline.
+func WithDeletionState(enabled bool) Option {
+	return func(g *Graph) {
+		g.deletionState = enabled
+	}
+}
+
+// WithCompactSourceLabels labels sources on a single line as "name (Kind)"
+// instead of listing the name, kind and group on separate lines.
+func WithCompactSourceLabels(enabled bool) Option {
+	return func(g *Graph) {
+		g.compactSourceLabels = enabled
+	}
+}
+
+// WithTriggerTable renders the triggers of a broker as a single table of
+// "type → subscriber" rows inside the broker cluster, instead of one node per
+// trigger.
+func WithTriggerTable(enabled bool) Option {
+	return func(g *Graph) {
+		g.triggerTable = enabled
+	}
+}
+
+// WithDeliveryDetails labels subscriber edges with the retry and backoff
+// settings of their delivery spec, drawing edges with more retries thicker.
+// Triggers use the delivery spec of their broker.
+func WithDeliveryDetails(enabled bool) Option {
+	return func(g *Graph) {
+		g.deliveryDetails = enabled
+	}
+}
+
+// WithGroupTriggersBySubscriber clusters the triggers of a broker by the
+// subscriber they deliver to, nested inside the broker cluster.
+func WithGroupTriggersBySubscriber(enabled bool) Option {
+	return func(g *Graph) {
+		g.groupTriggersBySubscriber = enabled
+	}
+}
+
+// WithAnnotationTooltips adds the values of the given annotation keys to the
+// tooltip of the nodes of resources carrying them.
+func WithAnnotationTooltips(keys ...string) Option {
+	return func(g *Graph) {
+		g.annotationTooltips = append(g.annotationTooltips, keys...)
+	}
+}
+
+// WithTopologyOnly renders only the brokers and channels, connected when
+// events flow from one to the other, hiding everything in between.
+func WithTopologyOnly(enabled bool) Option {
+	return func(g *Graph) {
+		g.topologyOnly = enabled
+	}
+}
+
+// WithReadinessColors colors resources and the edges into them by their Ready
+// condition, and dashes edges into resources that are not ready. It is on by
+// default.
+func WithReadinessColors(enabled bool) Option {
+	return func(g *Graph) {
+		g.readinessColors = enabled
+	}
+}
+
+// WithSinkEnvNames adds to the container env var names whose value is taken
+// as the sink of a Knative Service. SINK, TARGET and K_SINK are always
+// recognized.
+func WithSinkEnvNames(names ...string) Option {
+	return func(g *Graph) {
+		for _, name := range names {
+			g.sinkEnvNames[name] = true
+		}
+	}
+}
+
+// WithPartOfGrouping clusters resources by the application named in their
+// PartOfLabel.
+func WithPartOfGrouping(enabled bool) Option {
+	return func(g *Graph) {
+		g.partOfGrouping = enabled
+	}
+}
+
+// WithNamespaceClusters wraps the resources of each namespace in a cluster,
+// for graphs of more than one namespace. Keys become
+// "group/kind/namespace/name" so same named resources stay apart.
+func WithNamespaceClusters(enabled bool) Option {
+	return func(g *Graph) {
+		g.namespaceClusters = enabled
+	}
+}
+
+// WithTimestampNode adds a caption with the time the graph was rendered.
+func WithTimestampNode(enabled bool) Option {
+	return func(g *Graph) {
+		g.timestampNode = enabled
+	}
+}
+
+// WithOrdering sets the Graphviz ordering of edges, "out" or "in", to keep
+// layouts stable across renders.
+func WithOrdering(ordering string) Option {
+	return func(g *Graph) {
+		_ = g.Set("ordering", ordering)
+	}
+}
+
+// WithUnknownPrefix sets the text placeholder nodes for referenced but
+// missing resources of kind start with. The kinds are Broker, Sink and
+// Destination.
+func WithUnknownPrefix(kind, prefix string) Option {
+	return func(g *Graph) {
+		g.unknownPrefixes[kind] = prefix
+	}
+}
+
+// WithCountsInTitle appends the number of brokers, channels, triggers,
+// subscriptions and sources to the graph title when rendering.
+func WithCountsInTitle(enabled bool) Option {
+	return func(g *Graph) {
+		g.countsInTitle = enabled
+	}
+}
+
+// WithHighlightSources draws sources, where events enter the graph, with a
+// bold border and a "▶" marker.
+func WithHighlightSources(enabled bool) Option {
+	return func(g *Graph) {
+		g.highlightSources = enabled
+	}
+}
+
+// WithBackground sets the background color of the rendered graph.
+func WithBackground(color string) Option {
+	return func(g *Graph) {
+		_ = g.Set("bgcolor", color)
+	}
+}
+
+// WithResolvedURLs labels the edges to subscribers given by ref with the URL
+// the ref resolved to, from the Trigger or Subscription status.
+func WithResolvedURLs(enabled bool) Option {
+	return func(g *Graph) {
+		g.resolvedURLs = enabled
+	}
+}
+
+// WithMaxClusterDepth limits how deep clusters nest. The nodes of clusters
+// past the limit are placed in the deepest allowed cluster instead, with the
+// name of their own cluster as their group.
+func WithMaxClusterDepth(depth int) Option {
+	return func(g *Graph) {
+		g.maxClusterDepth = depth
+	}
+}
+
+// WithKeyFunc sets how the keys of resources, and of the references to them,
+// are built. By default keys are "group/kind/name", lower cased.
+func WithKeyFunc(fn KeyFunc) Option {
+	return func(g *Graph) {
+		g.keyFunc = fn
+	}
+}
+
+// WithDegreeLabels appends the number of outgoing edges to the label of each
+// node with any.
+func WithDegreeLabels(enabled bool) Option {
+	return func(g *Graph) {
+		g.degreeLabels = enabled
+	}
+}
+
+// WithOrphanCluster moves the nodes without any edge, and the clusters of
+// such nodes, into an "Unconnected" cluster when the graph is rendered.
+func WithOrphanCluster(enabled bool) Option {
+	return func(g *Graph) {
+		g.orphanCluster = enabled
+	}
+}
+
+// WithDefaultOpacity sets the fill opacity of every node, from 0 for clear to
+// 1 for opaque, so dimmed or layered graphs let what is behind show through.
+// SetOpacity overrides it per node.
+func WithDefaultOpacity(opacity float64) Option {
+	return func(g *Graph) {
+		g.defaultOpacity = opacity
+	}
+}
+
+// WithAlignTriggers keeps the triggers of each broker on one rank, so they
+// line up in a row.
+func WithAlignTriggers(enabled bool) Option {
+	return func(g *Graph) {
+		g.alignTriggers = enabled
+	}
+}
+
+// WithRatio sets the graphviz ratio of the rendered graph: "fill", "compress",
+// "expand" or "auto", or a number for the height to width ratio. "fill" and
+// "compress" take effect with a size set.
+func WithRatio(ratio string) Option {
+	return func(g *Graph) {
+		_ = g.Set("ratio", ratio)
+	}
+}
+
+// WithAspect lays the graph out to the aspect ratio of a width by height
+// area, for embedding it in a fixed layout.
+func WithAspect(width, height float64) Option {
+	return func(g *Graph) {
+		if width <= 0 || height <= 0 {
+			return
+		}
+		_ = g.Set("ratio", strconv.FormatFloat(height/width, 'f', -1, 64))
+	}
+}
+
+// WithRankDir sets the direction the graph is laid out in, "LR" by default.
+func WithRankDir(rankdir string) Option {
+	return func(g *Graph) {
+		_ = g.Set("rankdir", rankdir)
+	}
+}
+
+// WithRainbow colors reply edges from a rotating list of colors, so replies
+// can be told apart. It is on by default.
+func WithRainbow(enabled bool) Option {
+	return func(g *Graph) {
+		g.rainbowEdge = enabled
+	}
+}
+
+// WithTitle sets the label of the graph, "Triggers in <ns>" by default.
+func WithTitle(title string) Option {
+	return func(g *Graph) {
+		_ = g.Set("label", title)
+	}
+}
+
+// WithColors sets the colors reply edges rotate through with WithRainbow.
+// Blank colors are skipped, and a list with no colors left keeps the default
+// colors.
+func WithColors(colors []string) Option {
+	return func(g *Graph) {
+		var palette []string
+		for _, color := range colors {
+			if color = strings.TrimSpace(color); color != "" {
+				palette = append(palette, color)
+			}
+		}
+		if len(palette) > 0 {
+			g.colors = palette
+		}
+	}
+}
+
+// WithAgeGradient fills the nodes of resources along a gradient from green
+// for the newest to gray for the oldest, by creation time, to spot recent
+// changes.
+func WithAgeGradient(enabled bool) Option {
+	return func(g *Graph) {
+		g.ageGradient = enabled
+	}
+}
+
+// WithErrorBanners draws a red banner node for each malformed object added,
+// such as a broker without an address, besides the warning recorded for it.
+func WithErrorBanners(enabled bool) Option {
+	return func(g *Graph) {
+		g.errorBanners = enabled
+	}
+}
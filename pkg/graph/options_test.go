@@ -0,0 +1,1249 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmc/dot"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func TestWithLatencyLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+	}{
+		{name: "enabled", enabled: true},
+		{name: "disabled", enabled: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithLatencyLabels(tt.enabled))
+			g.AddBroker(newBroker("default", "default"))
+			trigger := newTrigger("default", "t", "default", *serviceRef("svc"))
+			trigger.Annotations = map[string]string{LatencyAnnotation: "120ms"}
+			g.AddTrigger(trigger)
+
+			if got := strings.Contains(g.String(), `label="120ms"`); got != tt.enabled {
+				t.Errorf("latency label drawn = %v, want %v", got, tt.enabled)
+			}
+		})
+	}
+}
+
+func TestWithClusterLabel(t *testing.T) {
+	tests := []struct {
+		name  string
+		label func(kind, name, dns string) string
+		want  string
+	}{{
+		name: "default",
+		want: "Broker default\nhttp://broker-ingress.knative-eventing.svc.cluster.local/default/default",
+	}, {
+		name:  "custom",
+		label: func(kind, name, dns string) string { return kind + ": " + name },
+		want:  "Broker: default",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithClusterLabel(tt.label))
+			g.AddBroker(newBroker("default", "default"))
+
+			if got := g.subgraphs["eventing.knative.dev/broker/default"].Get("label"); got != tt.want {
+				t.Errorf("cluster label = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithIngressPorts(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		want    []string
+	}{
+		{name: "enabled", enabled: true, want: []string{"e", "ne"}},
+		{name: "disabled", enabled: false, want: []string{"", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithIngressPorts(tt.enabled))
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t1", "default", *serviceRef("svc")))
+			g.AddTrigger(newTrigger("default", "t2", "default", *serviceRef("svc")))
+
+			for i, trigger := range []string{"t1", "t2"} {
+				e := findEdge(t, g, "eventing.knative.dev/broker/default", "eventing.knative.dev/trigger/"+trigger)
+				if got := e.Get("tailport"); got != tt.want[i] {
+					t.Errorf("tailport to %s = %q, want %q", trigger, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWithGroupSourcesBySink(t *testing.T) {
+	g := New("default", WithGroupSourcesBySink(true))
+	g.AddBroker(newBroker("default", "default"))
+	sink := "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"
+	g.AddSource(newSource("default", "a", sink))
+	g.AddSource(newSource("default", "b", sink))
+	g.AddSource(newSource("default", "c", "http://elsewhere.example.com"))
+
+	sg, ok := g.sourceGroups[sink]
+	if !ok {
+		t.Fatalf("no cluster for sources sinking to %s", sink)
+	}
+	out := sg.String()
+	for name, want := range map[string]bool{"a": true, "b": true, "c": false} {
+		if got := strings.Contains(out, "Source "+name); got != want {
+			t.Errorf("source %s in the shared sink cluster = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestWithTooltips(t *testing.T) {
+	sink := "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"
+	tests := []struct {
+		name    string
+		enabled bool
+		sink    string
+		to      string
+		want    string
+	}{{
+		name:    "known sink",
+		enabled: true,
+		sink:    sink,
+		to:      "eventing.knative.dev/broker/default",
+		want:    "events flow from ping to default",
+	}, {
+		name:    "unknown sink",
+		enabled: true,
+		sink:    "http://elsewhere.example.com",
+		to:      "uri/http://elsewhere.example.com",
+		want:    "events flow from ping to http://elsewhere.example.com",
+	}, {
+		name:    "disabled",
+		enabled: false,
+		sink:    sink,
+		to:      "eventing.knative.dev/broker/default",
+		want:    "",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithTooltips(tt.enabled), WithReadinessColors(false))
+			g.AddBroker(newBroker("default", "default"))
+			g.AddSource(newSource("default", "ping", tt.sink))
+
+			e := findEdge(t, g, "sources.knative.dev/pingsource/ping", tt.to)
+			if got := e.Get("tooltip"); got != tt.want {
+				t.Errorf("tooltip = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithDeletionState(t *testing.T) {
+	deleted := metav1.Now()
+	tests := []struct {
+		name     string
+		enabled  bool
+		deleted  *metav1.Time
+		wantDash bool
+	}{
+		{name: "terminating", enabled: true, deleted: &deleted, wantDash: true},
+		{name: "not terminating", enabled: true, deleted: nil, wantDash: false},
+		{name: "disabled", enabled: false, deleted: &deleted, wantDash: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithDeletionState(tt.enabled))
+			b := newBroker("default", "default")
+			b.DeletionTimestamp = tt.deleted
+			g.AddBroker(b)
+
+			n := g.nodes["eventing.knative.dev/broker/default"]
+			gotDash := n.Get("style") == "filled,dashed" && n.Get("color") == "red" &&
+				strings.HasPrefix(n.Get("tooltip"), "Terminating since ")
+			if gotDash != tt.wantDash {
+				t.Errorf("terminating style = %v, want %v (style %q, color %q, tooltip %q)",
+					gotDash, tt.wantDash, n.Get("style"), n.Get("color"), n.Get("tooltip"))
+			}
+		})
+	}
+}
+
+func TestWithCompactSourceLabels(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		wantLabel string
+		wantName  string
+	}{
+		{name: "compact", enabled: true, wantLabel: "ping (PingSource)", wantName: "Source ping\nPingSource\nsources.knative.dev"},
+		{name: "default", enabled: false, wantLabel: "", wantName: "Source ping\nPingSource\nsources.knative.dev"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithCompactSourceLabels(tt.enabled))
+			g.AddSource(newSource("default", "ping", ""))
+
+			n := g.nodes["sources.knative.dev/pingsource/ping"]
+			if got := n.Get("label"); got != tt.wantLabel {
+				t.Errorf("label = %q, want %q", got, tt.wantLabel)
+			}
+			if got := n.Name(); got != tt.wantName {
+				t.Errorf("name = %q, want %q", got, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestWithGroupTriggersBySubscriber(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		wantSame  bool
+		wantOther bool
+	}{
+		{name: "grouped", enabled: true, wantSame: true, wantOther: false},
+		{name: "not grouped", enabled: false, wantSame: true, wantOther: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithGroupTriggersBySubscriber(tt.enabled))
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t1", "default", *serviceRef("svc")))
+			g.AddTrigger(newTrigger("default", "t2", "default", *serviceRef("svc")))
+			g.AddTrigger(newTrigger("default", "t3", "default", *serviceRef("other")))
+
+			parent := func(name string) *dot.SubGraph {
+				return g.parent[g.nodes["eventing.knative.dev/trigger/"+name]]
+			}
+			if got := parent("t1") == parent("t2"); got != tt.wantSame {
+				t.Errorf("triggers to the same subscriber share a cluster = %v, want %v", got, tt.wantSame)
+			}
+			if got := parent("t1") == parent("t3"); got != tt.wantOther {
+				t.Errorf("triggers to different subscribers share a cluster = %v, want %v", got, tt.wantOther)
+			}
+			if tt.enabled {
+				if got := parent("t1").Get("label"); got != "to svc" {
+					t.Errorf("group label = %q, want %q", got, "to svc")
+				}
+				if got := g.clusterParent[parent("t1")]; got != g.subgraphs["eventing.knative.dev/broker/default"] {
+					t.Error("trigger group is not nested in its broker")
+				}
+			}
+		})
+	}
+}
+
+func TestWithAnnotationTooltips(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want string
+	}{{
+		name: "listed key",
+		keys: []string{"team"},
+		want: "team: payments",
+	}, {
+		name: "two keys",
+		keys: []string{"team", "owner"},
+		want: "team: payments\nowner: alice",
+	}, {
+		name: "unlisted key",
+		keys: []string{"missing"},
+		want: "",
+	}, {
+		name: "none",
+		want: "",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithAnnotationTooltips(tt.keys...), WithReadinessColors(false))
+			b := newBroker("default", "default")
+			b.Annotations = map[string]string{"team": "payments", "owner": "alice"}
+			g.AddBroker(b)
+
+			if got := g.nodes["eventing.knative.dev/broker/default"].Get("tooltip"); got != tt.want {
+				t.Errorf("tooltip = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTopologyOnly(t *testing.T) {
+	edge := `id="eventing.knative.dev/broker/default->messaging.knative.dev/inmemorychannel/ch:topology"`
+	tests := []struct {
+		name     string
+		enabled  bool
+		want     []string
+		wantGone []string
+	}{{
+		name:     "topology",
+		enabled:  true,
+		want:     []string{`"Broker `, `"InMemoryChannel ch"`, edge},
+		wantGone: []string{"Trigger t", "Subscription sub", `"svc\nService`, "subgraph"},
+	}, {
+		name:     "full",
+		enabled:  false,
+		want:     []string{`"Broker `, `"InMemoryChannel ch"`, "Trigger t", "Subscription sub", `"svc\nService`},
+		wantGone: []string{edge},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithTopologyOnly(tt.enabled))
+			g.AddBroker(newBroker("default", "default"))
+			g.AddInMemoryChannel(newChannel("default", "ch"))
+			g.AddTrigger(newTrigger("default", "t", "default", *channelRef("ch")))
+			g.AddSubscription(newSubscription("default", "sub", "ch", serviceRef("svc"), nil))
+
+			out := g.String()
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("rendered graph lacks %s:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.wantGone {
+				if strings.Contains(out, s) {
+					t.Errorf("rendered graph has %s:\n%s", s, out)
+				}
+			}
+			if got := strings.Count(out, "\" -> \""); tt.enabled && got != 1 {
+				t.Errorf("rendered %d edges, want 1:\n%s", got, out)
+			}
+		})
+	}
+}
+
+func TestWithReadinessColorsDashesNotReady(t *testing.T) {
+	tests := []struct {
+		name      string
+		readiness bool
+		ready     corev1.ConditionStatus
+		want      string
+	}{
+		{name: "not ready", readiness: true, ready: corev1.ConditionFalse, want: "dashed"},
+		{name: "unknown", readiness: true, ready: corev1.ConditionUnknown, want: "dashed"},
+		{name: "ready", readiness: true, ready: corev1.ConditionTrue, want: ""},
+		{name: "colors off", readiness: false, ready: corev1.ConditionFalse, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithReadinessColors(tt.readiness))
+			g.AddBroker(newBroker("default", "default"))
+			ch := newChannel("default", "ch")
+			ch.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionReady, Status: tt.ready}}
+			g.AddInMemoryChannel(ch)
+			g.AddTrigger(newTrigger("default", "t", "default", *channelRef("ch")))
+			_ = g.String()
+
+			e := findEdge(t, g, "eventing.knative.dev/trigger/t", "messaging.knative.dev/inmemorychannel/ch")
+			if got := e.Get("style"); got != tt.want {
+				t.Errorf("style = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithSinkEnvNames(t *testing.T) {
+	sink := "http://other.default.svc.cluster.local"
+	tests := []struct {
+		name string
+		opts []Option
+		env  string
+		want []Edge
+	}{{
+		name: "K_SINK by default",
+		env:  "K_SINK",
+		want: []Edge{{From: "serving.knative.dev/service/svc", To: "serving.knative.dev/service/other", Relationship: relSink}},
+	}, {
+		name: "custom name",
+		opts: []Option{WithSinkEnvNames("EVENTS_URL")},
+		env:  "EVENTS_URL",
+		want: []Edge{{From: "serving.knative.dev/service/svc", To: "serving.knative.dev/service/other", Relationship: relSink}},
+	}, {
+		name: "unknown name",
+		env:  "EVENTS_URL",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", tt.opts...)
+			g.LoadKnService(newKnService("default", "other"))
+			g.AddKnService(newKnService("default", "svc", corev1.EnvVar{Name: tt.env, Value: sink}))
+
+			got := edgesOf(g, relSink)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got sink edges %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("sink edge %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWithPartOfGrouping(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		wantSame bool
+	}{
+		{name: "grouped", enabled: true, wantSame: true},
+		{name: "not grouped", enabled: false, wantSame: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithPartOfGrouping(tt.enabled))
+			part := map[string]string{PartOfLabel: "shop"}
+			svc := newKnService("default", "svc")
+			svc.Labels = part
+			src := newSource("default", "ping", "http://svc.default.svc.cluster.local")
+			src.Labels = part
+			g.AddKnService(svc)
+			g.AddSource(src)
+			g.AddKnService(newKnService("default", "other"))
+
+			group := g.parent[g.nodes["serving.knative.dev/service/svc"]]
+			if got := group != nil && group == g.parent[g.nodes["sources.knative.dev/pingsource/ping"]]; got != tt.wantSame {
+				t.Errorf("resources part of shop share a cluster = %v, want %v", got, tt.wantSame)
+			}
+			if !tt.enabled {
+				return
+			}
+			if got := group.Get("label"); got != "shop" {
+				t.Errorf("group label = %q, want %q", got, "shop")
+			}
+			if g.parent[g.nodes["serving.knative.dev/service/other"]] == group {
+				t.Error("resource without the label is in the shop cluster")
+			}
+		})
+	}
+}
+
+func TestWithOrdering(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		want     string
+		wantGone bool
+	}{
+		{name: "out", opts: []Option{WithOrdering("out")}, want: "ordering=out;"},
+		{name: "in", opts: []Option{WithOrdering("in")}, want: "ordering=in;"},
+		{name: "unset", want: "ordering=", wantGone: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", tt.opts...)
+			g.AddBroker(newBroker("default", "default"))
+			if got := strings.Contains(g.String(), tt.want); got == tt.wantGone {
+				t.Errorf("rendered graph has %s = %v, want %v:\n%s", tt.want, got, !tt.wantGone, g.String())
+			}
+		})
+	}
+}
+
+func TestWithUnknownPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "default", want: "Unknown Broker missing"},
+		{name: "custom", opts: []Option{WithUnknownPrefix("Broker", "Missing broker")}, want: "Missing broker missing"},
+		{name: "other kind", opts: []Option{WithUnknownPrefix("Sink", "Missing sink")}, want: "Unknown Broker missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", tt.opts...)
+			g.AddTrigger(newTrigger("default", "t", "missing", *serviceRef("svc")))
+
+			if got := g.nodes["eventing.knative.dev/broker/missing"].Name(); got != tt.want {
+				t.Errorf("placeholder broker = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCountsInTitle(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		want    string
+	}{
+		{name: "counts", enabled: true, want: "Triggers in default\n1 broker, 2 triggers, 1 source"},
+		{name: "no counts", enabled: false, want: "Triggers in default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithCountsInTitle(tt.enabled))
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t1", "default", *serviceRef("svc")))
+			g.AddTrigger(newTrigger("default", "t2", "default", *serviceRef("svc")))
+			g.AddSource(newSource("default", "ping", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"))
+
+			// Rendering twice must not repeat the counts.
+			_ = g.String()
+			_ = g.String()
+			if got := g.Get("label"); got != tt.want {
+				t.Errorf("title = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithHighlightSources(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		wantLabel string
+		wantStyle string
+	}{
+		{name: "highlighted", enabled: true, wantLabel: "▶ Source ping\nPingSource\nsources.knative.dev", wantStyle: "filled,bold"},
+		{name: "plain", enabled: false, wantLabel: "", wantStyle: "filled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithHighlightSources(tt.enabled))
+			g.AddBroker(newBroker("default", "default"))
+			g.AddSource(newSource("default", "ping", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"))
+
+			source := g.nodes["sources.knative.dev/pingsource/ping"]
+			if got := source.Get("label"); got != tt.wantLabel {
+				t.Errorf("source label = %q, want %q", got, tt.wantLabel)
+			}
+			if got := source.Get("style"); got != tt.wantStyle {
+				t.Errorf("source style = %q, want %q", got, tt.wantStyle)
+			}
+			broker := g.nodes["eventing.knative.dev/broker/default"]
+			if strings.Contains(broker.Get("label"), "▶") || strings.Contains(broker.Get("style"), "bold") {
+				t.Errorf("broker is highlighted: label %q, style %q", broker.Get("label"), broker.Get("style"))
+			}
+		})
+	}
+}
+
+func TestWithBackground(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		want  string
+		found bool
+	}{
+		{name: "set", opts: []Option{WithBackground("lightgrey")}, want: "bgcolor=lightgrey;", found: true},
+		{name: "quoted", opts: []Option{WithBackground("#ffffff")}, want: `bgcolor="#ffffff";`, found: true},
+		{name: "unset", want: "bgcolor", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", tt.opts...)
+			if got := strings.Contains(g.String(), tt.want); got != tt.found {
+				t.Errorf("rendered graph has %s = %v, want %v:\n%s", tt.want, got, tt.found, g.String())
+			}
+		})
+	}
+}
+
+func TestWithReadinessColorsTooltip(t *testing.T) {
+	tests := []struct {
+		name      string
+		readiness bool
+		condition apis.Condition
+		want      string
+	}{{
+		name:      "not ready",
+		readiness: true,
+		condition: apis.Condition{Type: apis.ConditionReady, Status: corev1.ConditionFalse, Reason: "BrokerDoesNotExist", Message: "Broker \"default\" does not exist"},
+		want:      "[False] BrokerDoesNotExist: Broker \"default\" does not exist",
+	}, {
+		name:      "no message",
+		readiness: true,
+		condition: apis.Condition{Type: apis.ConditionReady, Status: corev1.ConditionUnknown, Reason: "Pending"},
+		want:      "[Unknown] Pending",
+	}, {
+		name:      "colors off",
+		readiness: false,
+		condition: apis.Condition{Type: apis.ConditionReady, Status: corev1.ConditionFalse, Reason: "BrokerDoesNotExist"},
+		want:      "",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithReadinessColors(tt.readiness))
+			trigger := newTrigger("default", "t", "default", *serviceRef("svc"))
+			trigger.Status.Conditions = duckv1.Conditions{tt.condition}
+			g.AddTrigger(trigger)
+
+			if got := g.nodes["eventing.knative.dev/trigger/t"].Get("tooltip"); got != tt.want {
+				t.Errorf("tooltip = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithResolvedURLs(t *testing.T) {
+	resolved := "http://svc.default.svc.cluster.local"
+	tests := []struct {
+		name    string
+		enabled bool
+		want    string
+	}{
+		{name: "shown", enabled: true, want: resolved},
+		{name: "hidden", enabled: false, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithResolvedURLs(tt.enabled))
+			g.AddBroker(newBroker("default", "default"))
+			trigger := newTrigger("default", "t", "default", *serviceRef("svc"))
+			trigger.Status.SubscriberURI = mustURL(resolved)
+			g.AddTrigger(trigger)
+			g.AddInMemoryChannel(newChannel("default", "ch"))
+			sub := newSubscription("default", "sub", "ch", serviceRef("svc"), nil)
+			sub.Status.PhysicalSubscription.SubscriberURI = mustURL(resolved)
+			g.AddSubscription(sub)
+
+			for _, from := range []string{"eventing.knative.dev/trigger/t", "messaging.knative.dev/subscription/sub"} {
+				e := findEdge(t, g, from, "serving.knative.dev/service/svc")
+				if got := e.Get("label"); got != tt.want {
+					t.Errorf("label of the edge from %s = %q, want %q", from, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAlignSequences(t *testing.T) {
+	tests := []struct {
+		name    string
+		rankdir string
+		want    int
+	}{
+		{name: "top to bottom", rankdir: "TB", want: 1},
+		{name: "bottom to top", rankdir: "BT", want: 1},
+		{name: "left to right", rankdir: "LR", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			_ = g.Set("rankdir", tt.rankdir)
+			seq := newSequence("default", "seq", "first", "second")
+			seq.Spec.Reply = serviceRef("last")
+			g.AddSequence(seq)
+
+			names := []string{"Sequence http://seq-kn-sequence-0-kn-channel.default.svc.cluster.local"}
+			for i := 0; i < 2; i++ {
+				names = append(names, g.sequenceStepKey("default", "seq", i))
+			}
+			names = append(names, "Reply http://seq-kn-sequence-0-kn-channel.default.svc.cluster.local")
+			quoted := make([]string, 0, len(names))
+			for _, name := range names {
+				quoted = append(quoted, dot.QuoteIfNecessary(name))
+			}
+			rank := "{ rank=same " + strings.Join(quoted, " ") + " }"
+
+			// Rendering twice must not rank the steps twice.
+			_ = g.String()
+			out := g.String()
+			if got := strings.Count(out, rank); got != tt.want {
+				t.Errorf("rendered %d ranks of the sequence steps, want %d:\n%s", got, tt.want, out)
+			}
+		})
+	}
+}
+
+func TestWithMaxClusterDepth(t *testing.T) {
+	tests := []struct {
+		name      string
+		depth     int
+		wantDepth int
+		wantGroup bool
+	}{
+		{name: "unlimited", depth: 0, wantDepth: 3},
+		{name: "at the limit", depth: 3, wantDepth: 3},
+		{name: "flattened", depth: 2, wantDepth: 2, wantGroup: true},
+		{name: "only the root", depth: 1, wantDepth: 1, wantGroup: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithMaxClusterDepth(tt.depth), WithPartOfGrouping(true), WithGroupTriggersBySubscriber(true))
+			broker := newBroker("default", "default")
+			broker.Labels = map[string]string{PartOfLabel: "shop"}
+			g.AddBroker(broker)
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+
+			trigger := g.nodes["eventing.knative.dev/trigger/t"]
+			if got := g.clusterDepth(g.parent[trigger]); got != tt.wantDepth {
+				t.Errorf("trigger is nested %d clusters deep, want %d", got, tt.wantDepth)
+			}
+			for _, sg := range g.clusters {
+				if got := g.clusterDepth(sg); got > tt.wantDepth {
+					t.Errorf("cluster %s is nested %d deep, past %d", sg.Name(), got, tt.wantDepth)
+				}
+			}
+			if got := trigger.Get("group") != ""; got != tt.wantGroup {
+				t.Errorf("trigger has a group = %v, want %v", got, tt.wantGroup)
+			}
+		})
+	}
+}
+
+func TestWithKeyFunc(t *testing.T) {
+	uids := map[string]string{
+		"eventing.knative.dev/Broker/default/default": "uid-broker",
+		"eventing.knative.dev/Trigger/default/t":      "uid-trigger",
+		"serving.knative.dev/Service/default/svc":     "uid-svc",
+		"sources.knative.dev/PingSource/default/ping": "uid-ping",
+	}
+	byUID := func(gvk schema.GroupVersionKind, ns, name string) string {
+		ref := gvk.Group + "/" + gvk.Kind + "/" + ns + "/" + name
+		if uid, ok := uids[ref]; ok {
+			return uid
+		}
+		return "unresolved/" + ref
+	}
+
+	g := New("default", WithKeyFunc(byUID))
+	g.LoadKnService(newKnService("default", "svc"))
+	g.AddBroker(newBroker("default", "default"))
+	g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+	g.AddKnService(newKnService("default", "svc"))
+	g.AddSource(newSource("default", "ping", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"))
+
+	want := []Edge{
+		{From: "uid-broker", To: "uid-trigger", Relationship: relTrigger},
+		{From: "uid-trigger", To: "uid-svc", Relationship: relSubscriber},
+		{From: "uid-ping", To: "uid-broker", Relationship: relSink},
+	}
+	var got []Edge
+	for _, e := range g.edges {
+		got = append(got, g.edgeFor(e))
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got edges %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("edge %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+	for k := range g.nodes {
+		if strings.HasPrefix(k, "unresolved/") {
+			t.Errorf("reference %s did not resolve", k)
+		}
+	}
+}
+
+// clusterBody returns the lines rendered inside the cluster named name,
+// including those of the clusters nested in it.
+func clusterBody(out, name string) string {
+	var body []string
+	depth := 0
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case depth == 0 && line == "subgraph "+name+" {":
+			depth = 1
+			continue
+		case depth == 0:
+			continue
+		case strings.HasSuffix(line, " {"):
+			depth++
+		case line == "}":
+			depth--
+		}
+		if depth > 0 {
+			body = append(body, line)
+		}
+	}
+	return strings.Join(body, "\n")
+}
+
+func TestWithOrphanCluster(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		want    []string
+		notWant []string
+	}{{
+		name:    "grouped",
+		enabled: true,
+		want:    []string{"/default/idle", `"alone\nService`},
+		notWant: []string{"Trigger t", "/default/default", `"svc\nService`},
+	}, {
+		name:    "scattered",
+		enabled: false,
+		notWant: []string{"/default/idle", `"alone\nService`},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithOrphanCluster(tt.enabled))
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+			g.AddBroker(newBroker("default", "idle"))
+			g.LoadKnService(newKnService("default", "alone"))
+
+			out := g.String()
+			body := clusterBody(out, "cluster_unconnected")
+			for _, s := range tt.want {
+				if !strings.Contains(body, s) {
+					t.Errorf("unconnected cluster lacks %s:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(body, s) {
+					t.Errorf("unconnected cluster has %s:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
+
+func TestWithAlignTriggers(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		before  []string // triggers added before a first render
+		after   []string // triggers added after it
+		want    string
+	}{{
+		name:   "disabled",
+		before: []string{"a", "b"},
+	}, {
+		name:    "single trigger",
+		enabled: true,
+		before:  []string{"a"},
+	}, {
+		name:    "triggers of a broker",
+		enabled: true,
+		before:  []string{"a", "b"},
+		want:    `{ rank=same "Trigger a" "Trigger b" }`,
+	}, {
+		name:    "trigger added since",
+		enabled: true,
+		before:  []string{"a", "b"},
+		after:   []string{"c"},
+		want:    `{ rank=same "Trigger a" "Trigger b" "Trigger c" }`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithAlignTriggers(tt.enabled))
+			g.AddBroker(newBroker("default", "default"))
+			g.AddBroker(newBroker("default", "other"))
+			g.AddTrigger(newTrigger("default", "elsewhere", "other", *serviceRef("svc")))
+			for _, name := range tt.before {
+				g.AddTrigger(newTrigger("default", name, "default", *serviceRef("svc")))
+			}
+			_ = g.String()
+			for _, name := range tt.after {
+				g.AddTrigger(newTrigger("default", name, "default", *serviceRef("svc")))
+			}
+			out := g.String()
+
+			if tt.want == "" {
+				if strings.Contains(out, "rank=same") {
+					t.Errorf("triggers are ranked:\n%s", out)
+				}
+				return
+			}
+			if got := strings.Count(out, tt.want); got != 1 {
+				t.Errorf("rendered %d ranks %s, want 1:\n%s", got, tt.want, out)
+			}
+			if strings.Contains(out, `rank=same "Trigger elsewhere"`) {
+				t.Errorf("trigger of another broker is ranked:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestWithRatio(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "unset"},
+		{name: "fill", opts: []Option{WithRatio("fill")}, want: "ratio=fill;"},
+		{name: "compress", opts: []Option{WithRatio("compress")}, want: "ratio=compress;"},
+		{name: "auto", opts: []Option{WithRatio("auto")}, want: "ratio=auto;"},
+		{name: "aspect", opts: []Option{WithAspect(4, 3)}, want: `ratio="0.75";`},
+		{name: "empty aspect", opts: []Option{WithAspect(0, 3)}},
+		{name: "last wins", opts: []Option{WithAspect(4, 3), WithRatio("fill")}, want: "ratio=fill;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", tt.opts...)
+			g.AddBroker(newBroker("default", "default"))
+			out := g.String()
+			if tt.want == "" {
+				if strings.Contains(out, "ratio=") {
+					t.Errorf("graph has a ratio:\n%s", out)
+				}
+				return
+			}
+			if got := strings.Count(out, "ratio="); got != 1 || !strings.Contains(out, tt.want) {
+				t.Errorf("graph does not have exactly %s:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+// replyColors returns the colors of the reply edges of n subscriptions.
+func replyColors(n int, opts ...Option) []string {
+	g := New("default", opts...)
+	g.AddInMemoryChannel(newChannel("default", "ch"))
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("sub-%d", i)
+		g.AddSubscription(newSubscription("default", name, "ch", serviceRef("svc"), serviceRef("reply-"+name)))
+	}
+	var colors []string
+	for _, e := range g.edges {
+		if e.rel == relReply {
+			colors = append(colors, e.Get("color"))
+		}
+	}
+	return colors
+}
+
+func TestNewOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		want        []string
+		wantReplies []string
+	}{{
+		name:        "defaults",
+		want:        []string{"rankdir=LR;", `label="Triggers in default";`},
+		wantReplies: []string{"aqua", "aquamarine"},
+	}, {
+		name:        "rank dir",
+		opts:        []Option{WithRankDir("TB")},
+		want:        []string{"rankdir=TB;", `label="Triggers in default";`},
+		wantReplies: []string{"aqua", "aquamarine"},
+	}, {
+		name:        "title",
+		opts:        []Option{WithTitle("Events")},
+		want:        []string{"rankdir=LR;", "label=Events;"},
+		wantReplies: []string{"aqua", "aquamarine"},
+	}, {
+		name:        "no rainbow",
+		opts:        []Option{WithRainbow(false)},
+		want:        []string{"rankdir=LR;"},
+		wantReplies: []string{"", ""},
+	}, {
+		name:        "colors",
+		opts:        []Option{WithColors([]string{"red"})},
+		want:        []string{"rankdir=LR;"},
+		wantReplies: []string{"red", "red"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := New("default", tt.opts...).String()
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("graph lacks %s:\n%s", s, out)
+				}
+			}
+			if got := replyColors(2, tt.opts...); !reflect.DeepEqual(got, tt.wantReplies) {
+				t.Errorf("reply edge colors = %q, want %q", got, tt.wantReplies)
+			}
+		})
+	}
+}
+
+func TestWithAgeGradient(t *testing.T) {
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		enabled bool
+		ages    []time.Duration // after created, by broker, or negative for none
+		want    []string
+	}{{
+		name: "disabled",
+		ages: []time.Duration{0, time.Hour},
+		want: []string{"white", "white"},
+	}, {
+		name:    "oldest to newest",
+		enabled: true,
+		ages:    []time.Duration{0, time.Hour, 2 * time.Hour},
+		want:    []string{"#d3d3d3", "#b1e0b1", "#90ee90"},
+	}, {
+		name:    "same age",
+		enabled: true,
+		ages:    []time.Duration{time.Hour, time.Hour},
+		want:    []string{"#90ee90", "#90ee90"},
+	}, {
+		name:    "no creation time",
+		enabled: true,
+		ages:    []time.Duration{0, -1},
+		want:    []string{"#90ee90", "white"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithAgeGradient(tt.enabled))
+			for i, age := range tt.ages {
+				b := newBroker("default", fmt.Sprintf("b%d", i))
+				if age >= 0 {
+					b.CreationTimestamp = metav1.NewTime(created.Add(age))
+				}
+				g.AddBroker(b)
+			}
+			_ = g.String()
+			for i, want := range tt.want {
+				n := g.nodes[fmt.Sprintf("eventing.knative.dev/broker/b%d", i)]
+				if got := n.Get("fillcolor"); got != want {
+					t.Errorf("broker b%d is filled %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithColorsPalette(t *testing.T) {
+	tests := []struct {
+		name   string
+		colors []string
+		want   []string
+	}{
+		{name: "two colors alternate", colors: []string{"red", "blue"}, want: []string{"red", "blue", "red"}},
+		{name: "blank colors skipped", colors: []string{"red", " ", "blue"}, want: []string{"red", "blue", "red"}},
+		{name: "empty palette", colors: nil, want: []string{"aqua", "aquamarine", "blanchedalmond"}},
+		{name: "only blank colors", colors: []string{"", " "}, want: []string{"aqua", "aquamarine", "blanchedalmond"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replyColors(3, WithColors(tt.colors)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reply edge colors = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithConcurrencyLabels(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		annotations map[string]string
+		want        string
+	}{{
+		name:        "disabled",
+		annotations: map[string]string{ConcurrencyAnnotation: "4"},
+	}, {
+		name:        "concurrency",
+		opts:        []Option{WithConcurrencyLabels(true)},
+		annotations: map[string]string{ConcurrencyAnnotation: "4"},
+		want:        "concurrency: 4",
+	}, {
+		name: "not annotated",
+		opts: []Option{WithConcurrencyLabels(true)},
+	}, {
+		name:        "with latency",
+		opts:        []Option{WithConcurrencyLabels(true), WithLatencyLabels(true)},
+		annotations: map[string]string{ConcurrencyAnnotation: "4", LatencyAnnotation: "50ms"},
+		want:        "50ms\nconcurrency: 4",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", tt.opts...)
+			g.AddBroker(newBroker("default", "default"))
+			trigger := newTrigger("default", "t", "default", *serviceRef("svc"))
+			trigger.Annotations = tt.annotations
+			g.AddTrigger(trigger)
+
+			e := findEdge(t, g, "eventing.knative.dev/trigger/t", "serving.knative.dev/service/svc")
+			if got := e.Get("label"); got != tt.want {
+				t.Errorf("subscriber edge label = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithAuthInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     bool
+		annotations map[string]string
+		want        bool
+	}{
+		{name: "disabled", annotations: map[string]string{AudienceAnnotation: "svc.example.com"}},
+		{name: "audience", enabled: true, annotations: map[string]string{AudienceAnnotation: "svc.example.com"}, want: true},
+		{name: "no audience", enabled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithAuthInfo(tt.enabled))
+			g.AddBroker(newBroker("default", "default"))
+			trigger := newTrigger("default", "t", "default", *serviceRef("svc"))
+			trigger.Annotations = tt.annotations
+			g.AddTrigger(trigger)
+			g.AddInMemoryChannel(newChannel("default", "ch"))
+			sub := newSubscription("default", "sub", "ch", serviceRef("svc"), nil)
+			sub.Annotations = tt.annotations
+			g.AddSubscription(sub)
+
+			for _, from := range []string{"eventing.knative.dev/trigger/t", "messaging.knative.dev/subscription/sub"} {
+				tooltip := findEdge(t, g, from, "serving.knative.dev/service/svc").Get("tooltip")
+				if got := strings.Contains(tooltip, "audience: svc.example.com"); got != tt.want {
+					t.Errorf("tooltip of the edge from %s is %q, want audience shown %v", from, tooltip, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithNamespaceClusters(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		want    []string
+		notWant []string
+	}{{
+		name:    "disabled",
+		enabled: false,
+		want:    []string{`label="Triggers in ";`},
+		notWant: []string{"cluster_ns_"},
+	}, {
+		name:    "enabled",
+		enabled: true,
+		want:    []string{"subgraph cluster_ns_a {", "subgraph cluster_ns_b {", `label="Triggers across 2 namespaces";`},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("", WithNamespaceClusters(tt.enabled))
+			for _, ns := range []string{"a", "b"} {
+				g.AddBroker(newBroker(ns, "default"))
+				g.AddTrigger(newTrigger(ns, "t", "default", *serviceRef("svc")))
+			}
+
+			out := g.String()
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("graph lacks %s:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(out, s) {
+					t.Errorf("graph has %s:\n%s", s, out)
+				}
+			}
+			if !tt.enabled {
+				return
+			}
+			for _, ns := range []string{"a", "b"} {
+				body := clusterBody(out, "cluster_ns_"+ns)
+				if !strings.Contains(body, "/"+ns+"/default") {
+					t.Errorf("namespace %s cluster lacks its broker:\n%s", ns, body)
+				}
+			}
+			if got := len(g.nodes); got != 6 {
+				t.Errorf("graph tracks %d nodes, want the broker, trigger and service of each namespace", got)
+			}
+		})
+	}
+}
+
+func TestWithNamespaceClustersCountsInTitle(t *testing.T) {
+	g := New("", WithNamespaceClusters(true), WithCountsInTitle(true))
+	for _, ns := range []string{"a", "b", "c"} {
+		g.AddBroker(newBroker(ns, "default"))
+		_ = g.String()
+	}
+
+	want := "Triggers across 3 namespaces\n3 brokers"
+	if got := g.Get("label"); got != want {
+		t.Errorf("title = %q, want %q", got, want)
+	}
+}
+
+func TestWithTimestampNode(t *testing.T) {
+	caption := regexp.MustCompile(`"generated at" \[fontsize="10", label="Generated ([^"]*)", shape=plaintext\];`)
+	tests := []struct {
+		name string
+		opts []Option
+		want bool
+	}{
+		{name: "off", want: false},
+		{name: "disabled", opts: []Option{WithTimestampNode(false)}, want: false},
+		{name: "enabled", opts: []Option{WithTimestampNode(true)}, want: true},
+		{name: "topology only", opts: []Option{WithTimestampNode(true), WithTopologyOnly(true)}, want: true},
+		{name: "orphan cluster", opts: []Option{WithTimestampNode(true), WithOrphanCluster(true)}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", tt.opts...)
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+
+			start := time.Now().UTC().Truncate(time.Second)
+			// Rendering again updates the caption rather than adding another.
+			for i := 0; i < 2; i++ {
+				out := g.String()
+				captions := caption.FindAllStringSubmatch(out, -1)
+				if !tt.want {
+					if len(captions) != 0 || strings.Contains(out, "generated at") {
+						t.Errorf("String() has a timestamp caption:\n%s", out)
+					}
+					continue
+				}
+				if len(captions) != 1 {
+					t.Fatalf("String() has %d timestamp captions, want 1:\n%s", len(captions), out)
+				}
+				at, err := time.Parse(time.RFC3339, captions[0][1])
+				if err != nil {
+					t.Fatalf("caption time: %v", err)
+				}
+				if at.Before(start) || at.After(time.Now().UTC()) {
+					t.Errorf("caption time %v is not the time of the render", at)
+				}
+			}
+		})
+	}
+}
+
+func TestWithReadinessPalette(t *testing.T) {
+	palette := WithReadinessPalette("green", "red", "gold")
+	tests := []struct {
+		name  string
+		opts  []Option
+		ready corev1.ConditionStatus
+		want  string
+	}{
+		{name: "default unknown", ready: corev1.ConditionUnknown, want: "darkorange2"},
+		{name: "unknown", opts: []Option{palette}, ready: corev1.ConditionUnknown, want: "gold"},
+		{name: "ready", opts: []Option{palette}, ready: corev1.ConditionTrue, want: "green"},
+		{name: "not ready", opts: []Option{palette}, ready: corev1.ConditionFalse, want: "red"},
+		{name: "no condition", opts: []Option{palette}, want: "purple"},
+		{name: "blank keeps default", opts: []Option{WithReadinessPalette("green", "", " ")}, ready: corev1.ConditionUnknown, want: "darkorange2"},
+		{name: "colors off", opts: []Option{palette, WithReadinessColors(false)}, ready: corev1.ConditionUnknown, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", tt.opts...)
+			g.AddBroker(newBroker("default", "default"))
+			trigger := newTrigger("default", "t", "default", *serviceRef("svc"))
+			if tt.ready != "" {
+				trigger.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionReady, Status: tt.ready}}
+			}
+			g.AddTrigger(trigger)
+
+			if got := g.nodes["eventing.knative.dev/trigger/t"].Get("color"); got != tt.want {
+				t.Errorf("trigger color = %q, want %q", got, tt.want)
+			}
+			e := findEdge(t, g, "eventing.knative.dev/broker/default", "eventing.knative.dev/trigger/t")
+			if got := e.Get("color"); got != tt.want {
+				t.Errorf("edge color = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
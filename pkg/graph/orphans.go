@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"sort"
+
+	"github.com/tmc/dot"
+)
+
+// FindOrphans returns the sorted keys of the nodes without any edge, such as
+// a source whose sink matched nothing known or a service nothing sends to.
+// Call it once the graph is complete.
+func (g *Graph) FindOrphans() []string {
+	orphaned := make(map[*dot.Node]bool)
+	for _, n := range g.orphans() {
+		orphaned[n] = true
+	}
+	var keys []string
+	for k, n := range g.nodes {
+		if orphaned[n] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// orphans returns the nodes without any edge, in the order they were added.
+func (g *Graph) orphans() []*dot.Node {
+	connected := make(map[*dot.Node]bool)
+	for _, e := range g.edges {
+		connected[e.Source()], connected[e.Destination()] = true, true
+	}
+	for _, e := range g.legend {
+		connected[e.Source()], connected[e.Destination()] = true, true
+	}
+	var orphans []*dot.Node
+	for _, n := range g.order {
+		if !connected[n] {
+			orphans = append(orphans, n)
+		}
+	}
+	return orphans
+}
+
+// clusterOrphans returns a copy of the graph with the nodes without any edge
+// moved into an "Unconnected" cluster. Clusters none of whose nodes have an
+// edge are moved there whole, so brokers and channels keep their labels;
+// orphans sharing a cluster with connected nodes stay where they are.
+func (g *Graph) clusterOrphans() *dot.Graph {
+	orphans := g.orphans()
+	if len(orphans) == 0 {
+		return g.Graph
+	}
+	orphaned := make(map[*dot.Node]bool, len(orphans))
+	for _, n := range orphans {
+		orphaned[n] = true
+	}
+	connected := make(map[*dot.SubGraph]bool)
+	for _, n := range g.order {
+		if orphaned[n] {
+			continue
+		}
+		for sg := g.parent[n]; sg != nil; sg = g.clusterParent[sg] {
+			connected[sg] = true
+		}
+	}
+
+	out := copyGraph(g.Graph)
+	unconnected := dot.NewSubgraph("cluster_unconnected")
+	_ = unconnected.Set("label", "Unconnected")
+
+	clusters := make(map[*dot.SubGraph]*dot.SubGraph, len(g.clusters))
+	for _, sg := range g.clusters {
+		c := copyCluster(sg, sg.Name())
+		clusters[sg] = c
+		parent, ok := g.clusterParent[sg]
+		switch {
+		case ok && (connected[parent] || !connected[sg]):
+			clusters[parent].AddSubgraph(c)
+		case connected[sg]:
+			out.AddSubgraph(c)
+		default:
+			unconnected.AddSubgraph(c)
+		}
+	}
+	for sg := range g.sequenceRanked {
+		if c, ok := clusters[sg]; ok {
+			g.rankSequence(c, sg)
+		}
+	}
+	if g.alignTriggers {
+		ranked, triggers := g.triggerRanks()
+		for _, sg := range ranked {
+			clusters[sg].SameRank(triggers[sg])
+		}
+	}
+	out.AddSubgraph(unconnected)
+
+	nodes := make(map[*dot.Node]*dot.Node, len(g.order))
+	for _, n := range g.order {
+		c := copyNode(n, n.Name())
+		nodes[n] = c
+		if sg, ok := g.parent[n]; ok {
+			clusters[sg].AddNode(c)
+		} else if orphaned[n] {
+			unconnected.AddNode(c)
+		} else {
+			out.AddNode(c)
+		}
+	}
+	for _, e := range g.edges {
+		out.AddEdge(copyEdge(e.Edge, nodes[e.Source()], nodes[e.Destination()]))
+	}
+	for _, e := range g.legend {
+		out.AddEdge(copyEdge(e, nodes[e.Source()], nodes[e.Destination()]))
+	}
+	return out
+}
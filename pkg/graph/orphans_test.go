@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindOrphans(t *testing.T) {
+	tests := []struct {
+		name string
+		add  func(g *Graph)
+		want []string
+	}{{
+		name: "connected",
+		add:  func(*Graph) {},
+	}, {
+		name: "unconnected service",
+		add: func(g *Graph) {
+			g.LoadKnService(newKnService("default", "alone"))
+		},
+		want: []string{"serving.knative.dev/service/alone"},
+	}, {
+		name: "idle broker and unconnected service",
+		add: func(g *Graph) {
+			g.LoadKnService(newKnService("default", "alone"))
+			g.AddBroker(newBroker("default", "idle"))
+		},
+		want: []string{"eventing.knative.dev/broker/idle", "serving.knative.dev/service/alone"},
+	}, {
+		name: "service given a subscriber later",
+		add: func(g *Graph) {
+			g.LoadKnService(newKnService("default", "alone"))
+			g.AddTrigger(newTrigger("default", "to-alone", "default", *serviceRef("alone")))
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+			g.AddSource(newSource("default", "ping", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"))
+			tt.add(g)
+
+			if got := g.FindOrphans(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindOrphans() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package graph
+
+import (
+	"sort"
+)
+
+// triggerFilter is the filter of a trigger, by the trigger key.
+type triggerFilter struct {
+	key        string
+	attributes map[string]string
+}
+
+// OverlappingTriggers groups the keys of the triggers on the same broker
+// whose filters can match the same event, which is then delivered once per
+// trigger. Each trigger in a group overlaps at least one other in it. Groups
+// are sorted, as are the keys in them.
+func (g *Graph) OverlappingTriggers() [][]string {
+	brokers := make([]string, 0, len(g.triggerFilters))
+	for bk := range g.triggerFilters {
+		brokers = append(brokers, bk)
+	}
+	sort.Strings(brokers)
+
+	var groups [][]string
+	for _, bk := range brokers {
+		var filters []triggerFilter
+		for _, f := range g.triggerFilters[bk] {
+			if _, ok := g.nodes[f.key]; ok {
+				filters = append(filters, f)
+			}
+		}
+
+		grouped := make([]bool, len(filters))
+		for i := range filters {
+			if grouped[i] {
+				continue
+			}
+			grouped[i] = true
+			group := []string{filters[i].key}
+			for queue := []int{i}; len(queue) > 0; queue = queue[1:] {
+				for j := range filters {
+					if !grouped[j] && filtersOverlap(filters[queue[0]].attributes, filters[j].attributes) {
+						grouped[j] = true
+						group = append(group, filters[j].key)
+						queue = append(queue, j)
+					}
+				}
+			}
+			if len(group) > 1 {
+				sort.Strings(group)
+				groups = append(groups, group)
+			}
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups
+}
+
+// filtersOverlap reports whether an event can match both filters, that is
+// whether no attribute both filter on must have different values. An empty
+// value matches any.
+func filtersOverlap(a, b map[string]string) bool {
+	for attr, v := range a {
+		if w, ok := b[attr]; ok && v != "" && w != "" && v != w {
+			return false
+		}
+	}
+	return true
+}
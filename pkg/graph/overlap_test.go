@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+)
+
+func filteredTrigger(name, broker string, attributes map[string]string) eventingv1beta1.Trigger {
+	t := newTrigger("default", name, broker, *serviceRef(name))
+	if attributes != nil {
+		t.Spec.Filter = &eventingv1beta1.TriggerFilter{Attributes: attributes}
+	}
+	return t
+}
+
+func TestOverlappingTriggers(t *testing.T) {
+	tests := []struct {
+		name     string
+		triggers []eventingv1beta1.Trigger
+		want     [][]string
+	}{{
+		name: "same filter",
+		triggers: []eventingv1beta1.Trigger{
+			filteredTrigger("a", "default", map[string]string{"type": "dev.example"}),
+			filteredTrigger("b", "default", map[string]string{"type": "dev.example"}),
+		},
+		want: [][]string{{"eventing.knative.dev/trigger/a", "eventing.knative.dev/trigger/b"}},
+	}, {
+		name: "different types",
+		triggers: []eventingv1beta1.Trigger{
+			filteredTrigger("a", "default", map[string]string{"type": "dev.example"}),
+			filteredTrigger("b", "default", map[string]string{"type": "dev.other"}),
+		},
+	}, {
+		name: "other broker",
+		triggers: []eventingv1beta1.Trigger{
+			filteredTrigger("a", "default", map[string]string{"type": "dev.example"}),
+			filteredTrigger("b", "other", map[string]string{"type": "dev.example"}),
+		},
+	}, {
+		name: "unfiltered matches all",
+		triggers: []eventingv1beta1.Trigger{
+			filteredTrigger("a", "default", map[string]string{"type": "dev.example"}),
+			filteredTrigger("b", "default", map[string]string{"type": "dev.other"}),
+			filteredTrigger("c", "default", nil),
+		},
+		want: [][]string{{"eventing.knative.dev/trigger/a", "eventing.knative.dev/trigger/b", "eventing.knative.dev/trigger/c"}},
+	}, {
+		name: "any value",
+		triggers: []eventingv1beta1.Trigger{
+			filteredTrigger("a", "default", map[string]string{"type": "dev.example", "source": ""}),
+			filteredTrigger("b", "default", map[string]string{"source": "ping"}),
+		},
+		want: [][]string{{"eventing.knative.dev/trigger/a", "eventing.knative.dev/trigger/b"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			g.AddBroker(newBroker("default", "other"))
+			for _, tr := range tt.triggers {
+				g.AddTrigger(tr)
+			}
+			if got := g.OverlappingTriggers(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OverlappingTriggers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
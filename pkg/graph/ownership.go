@@ -0,0 +1,79 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/tmc/dot"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// OwnershipGraph graphs the Kubernetes ownership of objs rather than the
+// event flow: one node per object, drawn as in the event flow graph, and an
+// edge from each owner to the objects it owns. Owners are matched by UID, or
+// by kind and name in the same namespace when the reference has no UID.
+// Objects the graph does not understand and owners not in objs are left
+// out. Nodes are keyed with their namespace, as with WithNamespaceClusters,
+// so same named objects of different namespaces stay apart.
+func OwnershipGraph(objs []runtime.Object) *Graph {
+	full := New("", WithNamespaceClusters(true))
+	for _, obj := range objs {
+		full.Add(obj)
+	}
+
+	g := New("", WithNamespaceClusters(true))
+	_ = g.Set("label", "Ownership")
+
+	type owned struct {
+		node *dot.Node
+		meta metav1.Object
+	}
+	var all []owned
+	byUID := make(map[string]*dot.Node)
+	byName := make(map[string]*dot.Node)
+	for _, obj := range objs {
+		m, ok := obj.(metav1.Object)
+		if !ok {
+			continue
+		}
+		k := full.objectKey(obj)
+		fn, ok := full.nodes[k]
+		if !ok {
+			continue
+		}
+		if _, ok := g.nodes[k]; ok {
+			continue
+		}
+		kind := full.info[k].kind
+		if kind == "" {
+			kind = reflect.TypeOf(obj).Elem().Name()
+		}
+
+		n := copyNode(fn, k)
+		_ = n.Set("label", escapeLabel(fmt.Sprintf("%s\n%s", kind, m.GetName())))
+		g.track(k, n)
+		g.info[k] = full.info[k]
+		g.addNodeTo(nil, n)
+
+		all = append(all, owned{node: n, meta: m})
+		if uid := string(m.GetUID()); uid != "" {
+			byUID[uid] = n
+		}
+		byName[m.GetNamespace()+"/"+kind+"/"+m.GetName()] = n
+	}
+
+	for _, o := range all {
+		for _, ref := range o.meta.GetOwnerReferences() {
+			owner, ok := byUID[string(ref.UID)]
+			if !ok && ref.UID == "" {
+				owner, ok = byName[o.meta.GetNamespace()+"/"+ref.Kind+"/"+ref.Name]
+			}
+			if !ok {
+				continue
+			}
+			g.addEdge(dot.NewEdge(owner, o.node), relOwner)
+		}
+	}
+	return g
+}
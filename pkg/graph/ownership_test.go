@@ -0,0 +1,89 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestOwnershipGraph(t *testing.T) {
+	// ownedChannel returns a channel in ns owned by the broker named broker,
+	// referenced by uid when it is set.
+	ownedChannel := func(ns, name, broker string, uid types.UID) runtime.Object {
+		c := newChannel(ns, name)
+		c.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "eventing.knative.dev/v1beta1",
+			Kind:       "Broker",
+			Name:       broker,
+			UID:        uid,
+		}}
+		return &c
+	}
+	broker := func(ns, name string, uid types.UID) runtime.Object {
+		b := newBroker(ns, name)
+		b.UID = uid
+		return &b
+	}
+	owns := func(ns, broker, channel string) Edge {
+		return Edge{
+			From:         "eventing.knative.dev/broker/" + ns + "/" + broker,
+			To:           "messaging.knative.dev/inmemorychannel/" + ns + "/" + channel,
+			Relationship: relOwner,
+		}
+	}
+	tests := []struct {
+		name string
+		objs []runtime.Object
+		want []Edge
+	}{{
+		name: "by uid",
+		objs: []runtime.Object{
+			broker("default", "default", "b1"),
+			ownedChannel("default", "kne", "default", "b1"),
+		},
+		want: []Edge{owns("default", "default", "kne")},
+	}, {
+		name: "by name",
+		objs: []runtime.Object{
+			broker("default", "default", ""),
+			ownedChannel("default", "kne", "default", ""),
+		},
+		want: []Edge{owns("default", "default", "kne")},
+	}, {
+		name: "owner missing",
+		objs: []runtime.Object{
+			broker("default", "default", "b1"),
+			ownedChannel("default", "kne", "default", "b2"),
+		},
+	}, {
+		name: "owner in another namespace",
+		objs: []runtime.Object{
+			broker("other", "default", ""),
+			ownedChannel("default", "kne", "default", ""),
+		},
+	}, {
+		name: "same names in two namespaces",
+		objs: []runtime.Object{
+			broker("a", "default", "a1"),
+			ownedChannel("a", "kne", "default", "a1"),
+			broker("b", "default", ""),
+			ownedChannel("b", "kne", "default", ""),
+		},
+		want: []Edge{owns("a", "default", "kne"), owns("b", "default", "kne")},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := OwnershipGraph(tt.objs)
+
+			if got := len(g.nodes); got != len(tt.objs) {
+				t.Errorf("OwnershipGraph() has %d nodes, want %d", got, len(tt.objs))
+			}
+			if got := edgesOf(g, relOwner); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("owner edges = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
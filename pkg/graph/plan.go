@@ -0,0 +1,231 @@
+package graph
+
+import (
+	"sync"
+
+	"github.com/tmc/dot"
+	"k8s.io/apimachinery/pkg/runtime"
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
+)
+
+// Edge describes a drawn edge by the keys of its endpoints.
+type Edge struct {
+	From         string
+	To           string
+	Relationship string
+}
+
+// PlanEntry is the node key an object would be added under and the edges
+// adding it would draw.
+type PlanEntry struct {
+	Key   string
+	Edges []Edge
+}
+
+// Plan reports what adding objs, in order, would add to the graph without
+// mutating it. Objects of unsupported kinds are left out of the plan.
+func (g *Graph) Plan(objs ...runtime.Object) []PlanEntry {
+	g.mu.Lock()
+	f := g.fork()
+	g.mu.Unlock()
+
+	plan := make([]PlanEntry, 0, len(objs))
+	for _, obj := range objs {
+		from := len(f.edges)
+		if !f.Add(obj) {
+			continue
+		}
+		entry := PlanEntry{Key: f.objectKey(obj)}
+		for _, e := range f.edges[from:] {
+			entry.Edges = append(entry.Edges, f.edgeFor(e))
+		}
+		plan = append(plan, entry)
+	}
+	return plan
+}
+
+func (g *Graph) edgeFor(e *edge) Edge {
+	return Edge{
+		From:         g.keyOf(e.Source()),
+		To:           g.keyOf(e.Destination()),
+		Relationship: e.rel,
+	}
+}
+
+// fork returns a copy of the graph that can be added to without changing
+// the original. Nodes, clusters and edges are copied too, as adding to the
+// copy can change existing ones, such as the label of a trigger table.
+func (g *Graph) fork() *Graph {
+	f := *g
+	f.mu = new(sync.Mutex)
+	f.Graph = copyGraph(g.Graph)
+	f.timestamp = nil
+	f.warnings = append([]string(nil), g.warnings...)
+
+	// Copies start without rank constraints, so renders rank them again.
+	f.sequenceRanked = make(map[*dot.SubGraph]bool)
+	f.triggersRanked = make(map[*dot.SubGraph]int)
+
+	clusters := make(map[*dot.SubGraph]*dot.SubGraph, len(g.clusters)+len(g.flattened))
+	for _, sg := range g.clusters {
+		clusters[sg] = copyCluster(sg, sg.Name())
+	}
+	for sg := range g.flattened {
+		clusters[sg] = copyCluster(sg, sg.Name())
+	}
+	cluster := func(sg *dot.SubGraph) *dot.SubGraph {
+		if c, ok := clusters[sg]; ok {
+			return c
+		}
+		c := copyCluster(sg, sg.Name())
+		clusters[sg] = c
+		return c
+	}
+	f.clusters = make([]*dot.SubGraph, 0, len(g.clusters))
+	f.clusterParent = make(map[*dot.SubGraph]*dot.SubGraph, len(g.clusterParent))
+	for _, sg := range g.clusters {
+		c := clusters[sg]
+		if parent, ok := g.clusterParent[sg]; ok {
+			f.clusterParent[c] = cluster(parent)
+			cluster(parent).AddSubgraph(c)
+		} else {
+			f.Graph.AddSubgraph(c)
+		}
+		f.clusters = append(f.clusters, c)
+	}
+	f.flattened = make(map[*dot.SubGraph]*dot.SubGraph, len(g.flattened))
+	for sg, into := range g.flattened {
+		f.flattened[clusters[sg]] = cluster(into)
+	}
+	for _, m := range []struct {
+		from map[string]*dot.SubGraph
+		to   *map[string]*dot.SubGraph
+	}{
+		{g.subgraphs, &f.subgraphs},
+		{g.sourceGroups, &f.sourceGroups},
+		{g.triggerGroups, &f.triggerGroups},
+		{g.partOfGroups, &f.partOfGroups},
+		{g.namespaceGroups, &f.namespaceGroups},
+	} {
+		*m.to = make(map[string]*dot.SubGraph, len(m.from))
+		for k, sg := range m.from {
+			(*m.to)[k] = cluster(sg)
+		}
+	}
+
+	nodes := make(map[*dot.Node]*dot.Node, len(g.order))
+	node := func(n *dot.Node) *dot.Node {
+		if c, ok := nodes[n]; ok {
+			return c
+		}
+		c := copyNode(n, n.Name())
+		nodes[n] = c
+		return c
+	}
+	f.order = make([]*dot.Node, 0, len(g.order))
+	f.parent = make(map[*dot.Node]*dot.SubGraph, len(g.parent))
+	for _, n := range g.order {
+		c := node(n)
+		if sg, ok := g.parent[n]; ok {
+			f.parent[c] = cluster(sg)
+			cluster(sg).AddNode(c)
+		} else {
+			f.Graph.AddNode(c)
+		}
+		f.order = append(f.order, c)
+	}
+	f.nodes = make(map[string]*dot.Node, len(g.nodes))
+	f.keys = make(map[*dot.Node]string, len(g.keys))
+	for k, n := range g.nodes {
+		f.track(k, node(n))
+	}
+
+	f.edges = make([]*edge, 0, len(g.edges))
+	for _, e := range g.edges {
+		c := copyEdge(e.Edge, node(e.Source()), node(e.Destination()))
+		f.edges = append(f.edges, &edge{Edge: c, rel: e.rel})
+		f.Graph.AddEdge(c)
+	}
+	f.legend = make([]*dot.Edge, 0, len(g.legend))
+	for _, e := range g.legend {
+		c := copyEdge(e, node(e.Source()), node(e.Destination()))
+		f.legend = append(f.legend, c)
+		f.Graph.AddEdge(c)
+	}
+
+	f.info = make(map[string]nodeInfo, len(g.info))
+	for k, v := range g.info {
+		f.info[k] = v
+	}
+	f.dnsToKey = make(map[string]string, len(g.dnsToKey))
+	for k, v := range g.dnsToKey {
+		f.dnsToKey[k] = v
+	}
+	f.triggerRows = make(map[string][]string, len(g.triggerRows))
+	for k, v := range g.triggerRows {
+		f.triggerRows[k] = append([]string(nil), v...)
+	}
+	f.parts = make(map[string][]string, len(g.parts))
+	for k, v := range g.parts {
+		f.parts[k] = append([]string(nil), v...)
+	}
+	f.sequenceSteps = make(map[string][]string, len(g.sequenceSteps))
+	for k, v := range g.sequenceSteps {
+		f.sequenceSteps[k] = append([]string(nil), v...)
+	}
+	f.brokerTypes = make(map[string][]string, len(g.brokerTypes))
+	for k, v := range g.brokerTypes {
+		f.brokerTypes[k] = append([]string(nil), v...)
+	}
+	f.brokerFilters = make(map[string][]string, len(g.brokerFilters))
+	for k, v := range g.brokerFilters {
+		f.brokerFilters[k] = append([]string(nil), v...)
+	}
+	f.triggerFilters = make(map[string][]triggerFilter, len(g.triggerFilters))
+	for k, v := range g.triggerFilters {
+		f.triggerFilters[k] = append([]triggerFilter(nil), v...)
+	}
+	f.brokerDelivery = make(map[string]*eventingduckv1beta1.DeliverySpec, len(g.brokerDelivery))
+	for k, v := range g.brokerDelivery {
+		f.brokerDelivery[k] = v
+	}
+	f.pendingDeadLetters = make(map[string][]*dot.Node, len(g.pendingDeadLetters))
+	for k, froms := range g.pendingDeadLetters {
+		for _, from := range froms {
+			f.pendingDeadLetters[k] = append(f.pendingDeadLetters[k], node(from))
+		}
+	}
+	f.opacity = make(map[*dot.Node]float64, len(g.opacity))
+	for n, v := range g.opacity {
+		f.opacity[node(n)] = v
+	}
+	f.fills = make(map[*dot.Node]fill, len(g.fills))
+	for n, v := range g.fills {
+		f.fills[node(n)] = v
+	}
+	f.ageFills = make(map[*dot.Node]string, len(g.ageFills))
+	for n, v := range g.ageFills {
+		f.ageFills[node(n)] = v
+	}
+	f.notReady = make(map[*dot.Node]bool, len(g.notReady))
+	for n, v := range g.notReady {
+		f.notReady[node(n)] = v
+	}
+	f.degreeSuffix = make(map[*dot.Node]string, len(g.degreeSuffix))
+	for n, v := range g.degreeSuffix {
+		f.degreeSuffix[node(n)] = v
+	}
+	f.edgeIDs = make(map[string]int, len(g.edgeIDs))
+	for k, v := range g.edgeIDs {
+		f.edgeIDs[k] = v
+	}
+	f.clusterNames = make(map[string]int, len(g.clusterNames))
+	for k, v := range g.clusterNames {
+		f.clusterNames[k] = v
+	}
+	f.ports = make(map[string]int, len(g.ports))
+	for k, v := range g.ports {
+		f.ports[k] = v
+	}
+	return &f
+}
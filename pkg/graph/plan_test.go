@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestPlan(t *testing.T) {
+	trigger := newTrigger("default", "t", "default", *serviceRef("svc"))
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []PlanEntry
+	}{{
+		name: "trigger",
+		want: []PlanEntry{{
+			Key: "eventing.knative.dev/trigger/t",
+			Edges: []Edge{
+				{From: "eventing.knative.dev/broker/default", To: "eventing.knative.dev/trigger/t", Relationship: relTrigger},
+				{From: "eventing.knative.dev/trigger/t", To: "serving.knative.dev/service/svc", Relationship: relSubscriber},
+			},
+		}},
+	}, {
+		name: "trigger table",
+		opts: []Option{WithTriggerTable(true)},
+		want: []PlanEntry{{
+			Key: "eventing.knative.dev/trigger/t",
+			Edges: []Edge{
+				{From: "eventing.knative.dev/broker/default/triggers", To: "serving.knative.dev/service/svc", Relationship: relSubscriber},
+			},
+		}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", tt.opts...)
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "first", "default", *serviceRef("svc")))
+			before := g.String()
+
+			got := g.Plan(&trigger)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Plan() = %v, want %v", got, tt.want)
+			}
+			if after := g.String(); after != before {
+				t.Errorf("Plan() changed the graph from\n%s\nto\n%s", before, after)
+			}
+		})
+	}
+}
+
+func TestPlanUnsupported(t *testing.T) {
+	g := New("default")
+	if got := g.Plan(&runtime.Unknown{}); len(got) != 0 {
+		t.Errorf("Plan() = %v, want nothing for an unsupported kind", got)
+	}
+}
@@ -0,0 +1,25 @@
+package graph
+
+import (
+	"strconv"
+)
+
+// ApplyPositions pins the nodes with the keys in pos at the given x and y, in
+// points, so re-rendering a changed graph does not move the nodes that were
+// already laid out. Keys without a node are skipped. Only the neato and fdp
+// layouts honor pinned positions; dot lays every node out again.
+func (g *Graph) ApplyPositions(pos map[string][2]float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for key, p := range pos {
+		n, ok := g.nodes[key]
+		if !ok {
+			continue
+		}
+		x := strconv.FormatFloat(p[0], 'f', -1, 64)
+		y := strconv.FormatFloat(p[1], 'f', -1, 64)
+		_ = n.Set("pos", x+","+y+"!")
+		_ = n.Set("pin", "true")
+	}
+}
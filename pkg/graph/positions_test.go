@@ -0,0 +1,54 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestApplyPositions(t *testing.T) {
+	const (
+		broker  = "eventing.knative.dev/broker/default"
+		trigger = "eventing.knative.dev/trigger/t"
+	)
+	tests := []struct {
+		name string
+		pos  map[string][2]float64
+		want map[string]string
+	}{{
+		name: "pinned",
+		pos:  map[string][2]float64{broker: {10, 20.5}, trigger: {-3, 0}},
+		want: map[string]string{broker: "10,20.5!", trigger: "-3,0!"},
+	}, {
+		name: "some nodes",
+		pos:  map[string][2]float64{broker: {10, 20}},
+		want: map[string]string{broker: "10,20!", trigger: ""},
+	}, {
+		name: "missing node",
+		pos:  map[string][2]float64{"eventing.knative.dev/broker/gone": {1, 2}},
+		want: map[string]string{broker: "", trigger: ""},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+			g.ApplyPositions(tt.pos)
+
+			if _, ok := g.nodes["eventing.knative.dev/broker/gone"]; ok {
+				t.Error("positioning a missing node added it")
+			}
+			for key, want := range tt.want {
+				n := g.nodes[key]
+				if got := n.Get("pos"); got != want {
+					t.Errorf("%s pos = %q, want %q", key, got, want)
+				}
+				wantPin := ""
+				if want != "" {
+					wantPin = "true"
+				}
+				if got := n.Get("pin"); got != wantPin {
+					t.Errorf("%s pin = %q, want %q", key, got, wantPin)
+				}
+			}
+		})
+	}
+}
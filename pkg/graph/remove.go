@@ -0,0 +1,242 @@
+package graph
+
+import (
+	"github.com/tmc/dot"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Delete removes the nodes drawn for obj and their edges from the graph. It
+// returns false if there is no node for obj.
+func (g *Graph) Delete(obj runtime.Object) bool {
+	return g.RemoveNode(g.objectKey(obj))
+}
+
+// RemoveNode removes the node with key, the step, branch and reply nodes
+// drawn with it, and every edge to or from them. It returns false if there
+// is no node with that key.
+func (g *Graph) RemoveNode(key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.nodes[key]; !ok {
+		return false
+	}
+	for _, part := range g.parts[key] {
+		g.removeNode(part)
+	}
+	delete(g.parts, key)
+	g.removeNode(key)
+
+	g.rebuild()
+	return true
+}
+
+// removeNode forgets the node with key, its edges and the clusters it leaves
+// empty. The dot graph still draws them until rebuild.
+func (g *Graph) removeNode(key string) {
+	n, ok := g.nodes[key]
+	if !ok {
+		return
+	}
+	holder := g.parent[n]
+	delete(g.nodes, key)
+	delete(g.info, key)
+	delete(g.parent, n)
+	delete(g.notReady, n)
+	delete(g.opacity, n)
+	delete(g.fills, n)
+	delete(g.ageFills, n)
+	delete(g.degreeSuffix, n)
+	delete(g.sequenceSteps, key)
+	for dns, k := range g.dnsToKey {
+		if k == key {
+			delete(g.dnsToKey, dns)
+		}
+	}
+	for target, froms := range g.pendingDeadLetters {
+		pending := froms[:0]
+		for _, from := range froms {
+			if from != n {
+				pending = append(pending, from)
+			}
+		}
+		g.pendingDeadLetters[target] = pending
+	}
+
+	order := g.order[:0]
+	for _, o := range g.order {
+		if o != n {
+			order = append(order, o)
+		}
+	}
+	g.order = order
+
+	removed := make(map[string]bool)
+	edges := g.edges[:0]
+	for _, e := range g.edges {
+		if e.Source() != n && e.Destination() != n {
+			edges = append(edges, e)
+		} else {
+			removed[g.baseEdgeID(e)] = true
+		}
+	}
+	g.edges = edges
+	// Ids still drawn keep their count, so new edges do not reuse the id of
+	// one left in place.
+	for _, e := range g.edges {
+		delete(removed, g.baseEdgeID(e))
+	}
+	for id := range removed {
+		delete(g.edgeIDs, id)
+	}
+	delete(g.keys, n)
+
+	if sg, ok := g.subgraphs[key]; ok {
+		delete(g.subgraphs, key)
+		if into, ok := g.flattened[sg]; ok {
+			holder = into
+		} else {
+			holder = g.clusterParent[sg]
+		}
+		g.dropCluster(sg)
+	}
+	for holder != nil && g.emptyCluster(holder) {
+		up := g.clusterParent[holder]
+		g.dropCluster(holder)
+		holder = up
+	}
+}
+
+// baseEdgeID returns the id e was drawn with, before addEdge numbered it.
+func (g *Graph) baseEdgeID(e *edge) string {
+	return edgeID(g.keyOf(e.Source()), g.keyOf(e.Destination()), e.rel)
+}
+
+// emptyCluster reports whether sg holds no nodes and no clusters.
+func (g *Graph) emptyCluster(sg *dot.SubGraph) bool {
+	for _, p := range g.parent {
+		if p == sg {
+			return false
+		}
+	}
+	for _, p := range g.clusterParent {
+		if p == sg {
+			return false
+		}
+	}
+	for _, into := range g.flattened {
+		if into == sg {
+			return false
+		}
+	}
+	return true
+}
+
+// dropCluster forgets the cluster sg, moving what it holds to the cluster
+// holding it, or to the root.
+func (g *Graph) dropCluster(sg *dot.SubGraph) {
+	up, nested := g.clusterParent[sg]
+	if into, ok := g.flattened[sg]; ok {
+		up, nested = into, into != nil
+	}
+	for n, p := range g.parent {
+		if p != sg {
+			continue
+		}
+		if nested {
+			g.parent[n] = up
+		} else {
+			delete(g.parent, n)
+		}
+	}
+	for c, p := range g.clusterParent {
+		if p != sg {
+			continue
+		}
+		if nested {
+			g.clusterParent[c] = up
+		} else {
+			delete(g.clusterParent, c)
+		}
+	}
+	for c, into := range g.flattened {
+		if into == sg {
+			g.flattened[c] = up
+		}
+	}
+	delete(g.clusterParent, sg)
+	delete(g.flattened, sg)
+	delete(g.sequenceRanked, sg)
+	delete(g.triggersRanked, sg)
+
+	clusters := g.clusters[:0]
+	for _, c := range g.clusters {
+		if c != sg {
+			clusters = append(clusters, c)
+		}
+	}
+	g.clusters = clusters
+	for _, m := range []map[string]*dot.SubGraph{g.subgraphs, g.sourceGroups, g.triggerGroups, g.partOfGroups, g.namespaceGroups} {
+		for k, c := range m {
+			if c == sg {
+				delete(m, k)
+			}
+		}
+	}
+}
+
+// rebuild replaces the dot graph with one built from the tracked nodes,
+// clusters and edges. dot has no way to remove objects, so this is how they
+// are dropped.
+func (g *Graph) rebuild() {
+	out := copyGraph(g.Graph)
+
+	clusters := make(map[*dot.SubGraph]*dot.SubGraph, len(g.clusters))
+	for _, sg := range g.clusters {
+		clusters[sg] = copyCluster(sg, sg.Name())
+	}
+	clusterParent := make(map[*dot.SubGraph]*dot.SubGraph, len(g.clusterParent))
+	for i, sg := range g.clusters {
+		c := clusters[sg]
+		if parent, ok := g.clusterParent[sg]; ok {
+			clusterParent[c] = clusters[parent]
+			clusters[parent].AddSubgraph(c)
+		} else {
+			out.AddSubgraph(c)
+		}
+		g.clusters[i] = c
+	}
+	g.clusterParent = clusterParent
+	// Flattened clusters are never drawn, so they are kept as they are and
+	// only pointed at the copies holding their nodes.
+	for sg, into := range g.flattened {
+		clusters[sg] = sg
+		if into != nil {
+			g.flattened[sg] = clusters[into]
+		}
+	}
+	for _, n := range g.order {
+		if sg, ok := g.parent[n]; ok {
+			g.parent[n] = clusters[sg]
+			clusters[sg].AddNode(n)
+		} else {
+			out.AddNode(n)
+		}
+	}
+	for _, e := range g.edges {
+		out.AddEdge(e.Edge)
+	}
+	for _, e := range g.legend {
+		out.AddEdge(e)
+	}
+	if g.timestamp != nil {
+		out.AddNode(g.timestamp)
+	}
+
+	for _, m := range []map[string]*dot.SubGraph{g.subgraphs, g.sourceGroups, g.triggerGroups, g.partOfGroups, g.namespaceGroups} {
+		for k, sg := range m {
+			m[k] = clusters[sg]
+		}
+	}
+	g.Graph = out
+}
@@ -0,0 +1,171 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDelete(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "default"))
+	trigger := newTrigger("default", "t", "default", *serviceRef("svc"))
+	g.AddTrigger(trigger)
+
+	if !g.Delete(&trigger) {
+		t.Fatal("Delete() = false, want true")
+	}
+	if g.Delete(&trigger) {
+		t.Error("second Delete() = true, want false")
+	}
+	if _, ok := g.nodes["eventing.knative.dev/trigger/t"]; ok {
+		t.Error("trigger node is still tracked")
+	}
+	for _, e := range g.Edges() {
+		if e.From == "eventing.knative.dev/trigger/t" || e.To == "eventing.knative.dev/trigger/t" {
+			t.Errorf("edge %v of the trigger is still drawn", e)
+		}
+	}
+	if out := g.String(); strings.Contains(out, "Trigger t") {
+		t.Errorf("trigger is still rendered:\n%s", out)
+	}
+	if err := g.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestDeleteFlows(t *testing.T) {
+	seq := newSequence("default", "seq", "a", "b")
+	seq.Spec.Reply = serviceRef("c")
+	par := newParallel("default", "par", "a", "b")
+	par.Spec.Reply = serviceRef("c")
+
+	tests := []struct {
+		name string
+		obj  runtime.Object
+	}{{
+		name: "sequence",
+		obj:  &seq,
+	}, {
+		name: "parallel",
+		obj:  &par,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.Add(tt.obj)
+
+			if !g.Delete(tt.obj) {
+				t.Fatal("Delete() = false, want true")
+			}
+			for k := range g.Nodes() {
+				if strings.HasPrefix(k, "flows.knative.dev/") {
+					t.Errorf("node %s is still tracked", k)
+				}
+			}
+			if edges := g.Edges(); len(edges) != 0 {
+				t.Errorf("edges %v are still drawn", edges)
+			}
+			if out := g.String(); strings.Contains(out, "Step") || strings.Contains(out, "Branch") || strings.Contains(out, "Reply") {
+				t.Errorf("flow nodes are still rendered:\n%s", out)
+			}
+			if err := g.Validate(); err != nil {
+				t.Errorf("Validate() = %v", err)
+			}
+		})
+	}
+}
+
+func TestRemoveNodeClusters(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []Option
+		remove       string
+		wantGone     string
+		wantKept     string
+		wantClusters int
+	}{{
+		name:         "emptied trigger group",
+		opts:         []Option{WithGroupTriggersBySubscriber(true)},
+		remove:       "eventing.knative.dev/trigger/t",
+		wantGone:     `label="to svc"`,
+		wantKept:     "Broker default",
+		wantClusters: 1,
+	}, {
+		name:     "own cluster",
+		remove:   "eventing.knative.dev/broker/default",
+		wantGone: "subgraph",
+		wantKept: "Trigger t",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", tt.opts...)
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+
+			if !g.RemoveNode(tt.remove) {
+				t.Fatalf("RemoveNode(%q) = false, want true", tt.remove)
+			}
+			out := g.String()
+			if strings.Contains(out, tt.wantGone) {
+				t.Errorf("rendered graph has %s:\n%s", tt.wantGone, out)
+			}
+			if !strings.Contains(out, tt.wantKept) {
+				t.Errorf("rendered graph lacks %s:\n%s", tt.wantKept, out)
+			}
+			if got := len(g.clusters); got != tt.wantClusters {
+				t.Errorf("%d clusters are tracked, want %d", got, tt.wantClusters)
+			}
+		})
+	}
+}
+
+func TestRemoveNodeEdgeIDs(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "default"))
+	trigger := newTrigger("default", "t", "default", *serviceRef("svc"))
+	g.AddTrigger(trigger)
+	g.Delete(&trigger)
+	g.AddTrigger(trigger)
+
+	want := edgeID("eventing.knative.dev/broker/default", "eventing.knative.dev/trigger/t", relTrigger)
+	e := findEdge(t, g, "eventing.knative.dev/broker/default", "eventing.knative.dev/trigger/t")
+	if got := e.Get("id"); got != want {
+		t.Errorf("id of the redrawn edge = %q, want %q", got, want)
+	}
+}
+
+func TestRemoveNodeClusterNames(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "first"))
+	g.AddBroker(newBroker("default", "second"))
+	g.RemoveNode("eventing.knative.dev/broker/first")
+	g.AddBroker(newBroker("default", "third"))
+
+	second := g.subgraphs["eventing.knative.dev/broker/second"].Name()
+	third := g.subgraphs["eventing.knative.dev/broker/third"].Name()
+	if second == third {
+		t.Errorf("brokers second and third are both drawn in cluster %q", second)
+	}
+}
+
+func TestRemoveNodeFlattenedCluster(t *testing.T) {
+	g := New("default", WithMaxClusterDepth(1), WithGroupTriggersBySubscriber(true))
+	g.AddBroker(newBroker("default", "default"))
+	g.AddTrigger(newTrigger("default", "t1", "default", *serviceRef("svc")))
+	g.AddTrigger(newTrigger("default", "t2", "default", *serviceRef("svc")))
+	g.RemoveNode("eventing.knative.dev/trigger/t2")
+	g.AddTrigger(newTrigger("default", "t3", "default", *serviceRef("svc")))
+
+	broker := g.subgraphs["eventing.knative.dev/broker/default"]
+	for _, name := range []string{"t1", "t3"} {
+		n := g.nodes["eventing.knative.dev/trigger/"+name]
+		if got := g.parent[n]; got != broker {
+			t.Errorf("trigger %s is drawn in %v, want the broker cluster", name, got)
+		}
+	}
+	if err := g.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/tmc/dot"
+)
+
+// String renders the graph as DOT.
+func (g *Graph) String() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.render().String()
+}
+
+// WriteTo writes the graph as DOT to w, for example to pipe it to graphviz.
+// It implements io.WriterTo.
+func (g *Graph) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, g.String())
+	return int64(n), err
+}
+
+// render returns the dot graph to output. Options that change the shape of
+// the whole graph are applied here, on a copy, so the graph can keep being
+// added to.
+func (g *Graph) render() *dot.Graph {
+	if len(g.includeOnly) > 0 {
+		return g.included().render()
+	}
+	g.dashNotReady()
+	if g.ageGradient {
+		g.fillByAge()
+	}
+	g.applyOpacity()
+	g.alignSequences()
+	if g.alignTriggers {
+		g.alignBrokerTriggers()
+	}
+	if g.countsInTitle {
+		g.setCountsInTitle()
+	}
+	if g.degreeLabels {
+		g.setDegreeLabels()
+	}
+	out := g.Graph
+	switch {
+	case g.topologyOnly:
+		out = g.topology()
+	case g.orphanCluster:
+		out = g.clusterOrphans()
+	}
+	if g.timestampNode {
+		g.stampTime(out)
+	}
+	return out
+}
+
+// stampTime adds a caption with the time of the render to out. The caption
+// added to the graph itself is kept and updated by later renders.
+func (g *Graph) stampTime(out *dot.Graph) {
+	label := "Generated " + time.Now().UTC().Format(time.RFC3339)
+	if out != g.Graph {
+		out.AddNode(timestampNode(label))
+		return
+	}
+	if g.timestamp == nil {
+		g.timestamp = timestampNode(label)
+		g.Graph.AddNode(g.timestamp)
+	}
+	_ = g.timestamp.Set("label", label)
+}
+
+func timestampNode(label string) *dot.Node {
+	n := dot.NewNode("generated at")
+	_ = n.Set("shape", "plaintext")
+	_ = n.Set("fontsize", "10")
+	_ = n.Set("label", label)
+	return n
+}
+
+// dashNotReady dashes the edges into resources that are not ready.
+func (g *Graph) dashNotReady() {
+	for _, e := range g.edges {
+		if !g.notReady[e.Destination()] {
+			continue
+		}
+		style := e.Get("style")
+		if strings.Contains(style, "dashed") {
+			continue
+		}
+		if style != "" {
+			style += ","
+		}
+		_ = e.Set("style", style+"dashed")
+	}
+}
+
+// alignSequences keeps the steps of each sequence on one rank when the graph
+// is laid out top to bottom, so sequences always read left to right.
+func (g *Graph) alignSequences() {
+	switch g.Get("rankdir") {
+	case "LR", "RL":
+		// Steps already follow each other left to right.
+		return
+	}
+	for key := range g.sequenceSteps {
+		sg, ok := g.subgraphs[key]
+		if !ok || g.sequenceRanked[sg] {
+			continue
+		}
+		g.rankSequence(sg, sg)
+		g.sequenceRanked[sg] = true
+	}
+}
+
+// rankSequence puts the steps of the sequence drawn as cluster seq on one
+// rank of sg, which is seq or a copy of it.
+func (g *Graph) rankSequence(sg, seq *dot.SubGraph) {
+	for key, names := range g.sequenceSteps {
+		if g.subgraphs[key] != seq {
+			continue
+		}
+		quoted := make([]string, 0, len(names))
+		for _, name := range names {
+			quoted = append(quoted, dot.QuoteIfNecessary(name))
+		}
+		sg.SameRank(quoted)
+	}
+}
+
+// alignBrokerTriggers puts the triggers of each broker on one rank. Triggers
+// grouped into nested clusters are ranked within their cluster. Triggers
+// added since the last render are ranked with the ones before them.
+func (g *Graph) alignBrokerTriggers() {
+	clusters, triggers := g.triggerRanks()
+	for _, sg := range clusters {
+		names := triggers[sg]
+		if g.triggersRanked[sg] == len(names) {
+			continue
+		}
+		// Overlapping rank=same sets are merged, so ranking again with the
+		// new triggers keeps the earlier ones on the same rank.
+		sg.SameRank(names)
+		g.triggersRanked[sg] = len(names)
+	}
+}
+
+// triggerRanks returns the clusters holding more than one trigger, in the
+// order added, with the quoted names of the triggers in each.
+func (g *Graph) triggerRanks() ([]*dot.SubGraph, map[*dot.SubGraph][]string) {
+	triggers := make(map[*dot.SubGraph][]string)
+	var clusters []*dot.SubGraph
+	for _, n := range g.order {
+		sg, ok := g.parent[n]
+		if !ok || g.info[g.keyOf(n)].kind != "Trigger" {
+			continue
+		}
+		triggers[sg] = append(triggers[sg], dot.QuoteIfNecessary(n.Name()))
+		if len(triggers[sg]) == 2 {
+			clusters = append(clusters, sg)
+		}
+	}
+	return clusters, triggers
+}
+
+// titleCounts are the kinds counted in the title, by the plural they are
+// counted as.
+var titleCounts = []struct {
+	singular, plural string
+	match            func(kind string) bool
+}{
+	{"broker", "brokers", func(kind string) bool { return kind == "Broker" }},
+	{"channel", "channels", func(kind string) bool { return kind == "Channel" || kind == "InMemoryChannel" }},
+	{"trigger", "triggers", func(kind string) bool { return kind == "Trigger" }},
+	{"subscription", "subscriptions", func(kind string) bool { return kind == "Subscription" }},
+	{"source", "sources", func(kind string) bool { return strings.HasSuffix(kind, "Source") }},
+}
+
+// setCountsInTitle appends the number of resources of each counted kind to
+// the graph label, replacing the counts from an earlier render.
+func (g *Graph) setCountsInTitle() {
+	counts := make([]int, len(titleCounts))
+	for _, info := range g.info {
+		for i, c := range titleCounts {
+			if c.match(info.kind) {
+				counts[i]++
+			}
+		}
+	}
+	var parts []string
+	for i, c := range titleCounts {
+		switch counts[i] {
+		case 0:
+		case 1:
+			parts = append(parts, "1 "+c.singular)
+		default:
+			parts = append(parts, fmt.Sprintf("%d %s", counts[i], c.plural))
+		}
+	}
+
+	label := strings.TrimSuffix(g.Get("label"), g.titleSuffix)
+	g.titleSuffix = ""
+	if len(parts) > 0 {
+		g.titleSuffix = "\n" + strings.Join(parts, ", ")
+	}
+	_ = g.Set("label", label+g.titleSuffix)
+}
+
+// isIngress reports whether the node with key is a broker or channel.
+func (g *Graph) isIngress(key string) bool {
+	switch g.info[key].kind {
+	case "Broker", "Channel", "InMemoryChannel":
+		return true
+	}
+	return false
+}
+
+// topology renders only the brokers and channels, with an edge between two
+// of them when events flow from one to the other through anything but
+// another broker or channel.
+func (g *Graph) topology() *dot.Graph {
+	out := copyGraph(g.Graph)
+	adj := g.adjacency()
+
+	nodes := make(map[string]*dot.Node)
+	for _, n := range g.order {
+		key := g.keyOf(n)
+		if !g.isIngress(key) {
+			continue
+		}
+		c := copyNode(n, n.Name())
+		if sg, ok := g.subgraphs[key]; ok {
+			// The cluster label names the broker or channel.
+			_ = c.Set("label", sg.Get("label"))
+		}
+		nodes[key] = c
+		out.AddNode(c)
+	}
+
+	for _, n := range g.order {
+		from := g.keyOf(n)
+		src, ok := nodes[from]
+		if !ok {
+			continue
+		}
+		seen := map[string]bool{from: true}
+		for _, to := range g.nextIngress(from, adj, seen) {
+			e := dot.NewEdge(src, nodes[to])
+			_ = e.Set("id", edgeID(from, to, "topology"))
+			out.AddEdge(e)
+		}
+	}
+	return out
+}
+
+// nextIngress returns the brokers and channels reachable from key without
+// passing through another broker or channel.
+func (g *Graph) nextIngress(key string, adj map[string][]string, seen map[string]bool) []string {
+	var found []string
+	for _, next := range adj[key] {
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		if g.isIngress(next) {
+			found = append(found, next)
+			continue
+		}
+		found = append(found, g.nextIngress(next, adj, seen)...)
+	}
+	return found
+}
@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// limitWriter keeps up to n bytes and then fails with err.
+type limitWriter struct {
+	buf bytes.Buffer
+	n   int
+	err error
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n, _ := w.buf.Write(p[:w.n])
+		w.n = 0
+		return n, w.err
+	}
+	w.n -= len(p)
+	return w.buf.Write(p)
+}
+
+func TestWriteTo(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "default"))
+	want := g.String()
+	for _, s := range []string{"digraph G {", `"Broker http://broker-ingress.knative-eventing.svc.cluster.local/default/default"`} {
+		if !strings.Contains(want, s) {
+			t.Fatalf("String() lacks %s:\n%s", s, want)
+		}
+	}
+
+	errWrite := errors.New("write failed")
+	tests := []struct {
+		name    string
+		limit   int
+		wantN   int64
+		wantErr error
+	}{
+		{name: "whole graph", limit: len(want), wantN: int64(len(want))},
+		{name: "failing writer", limit: 10, wantN: 10, wantErr: errWrite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &limitWriter{n: tt.limit, err: errWrite}
+			n, err := g.WriteTo(w)
+			if err != tt.wantErr {
+				t.Errorf("WriteTo() error = %v, want %v", err, tt.wantErr)
+			}
+			if n != tt.wantN {
+				t.Errorf("WriteTo() = %d bytes, want %d", n, tt.wantN)
+			}
+			if got := w.buf.String(); got != want[:tt.wantN] {
+				t.Errorf("WriteTo() wrote\n%s\nwant\n%s", got, want[:tt.wantN])
+			}
+		})
+	}
+}
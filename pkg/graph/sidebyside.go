@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tmc/dot"
+)
+
+// SideBySide combines two graphs into one, each drawn in its own top-level
+// cluster labeled leftLabel and rightLabel. Node keys are prefixed with
+// "left/" and "right/" so the two sides do not collide.
+func SideBySide(left, right *Graph, leftLabel, rightLabel string) *Graph {
+	g := New("")
+	_ = g.Set("label", fmt.Sprintf("%s | %s", leftLabel, rightLabel))
+
+	g.embed("left", leftLabel, left)
+	g.embed("right", rightLabel, right)
+	return g
+}
+
+// embed copies other into a new top-level cluster of g. Node keys, node
+// names and cluster names are prefixed so they stay unique within g.
+func (g *Graph) embed(prefix, label string, other *Graph) {
+	outer := dot.NewSubgraph("cluster_" + prefix)
+	_ = outer.Set("label", label)
+	g.addCluster(nil, outer)
+
+	clusters := make(map[*dot.SubGraph]*dot.SubGraph, len(other.clusters))
+	names := make(map[string]string, len(other.clusters))
+	for _, sg := range other.clusters {
+		name := "cluster_" + prefix + "_" + strings.TrimPrefix(sg.Name(), "cluster_")
+		c := copyCluster(sg, name)
+		clusters[sg] = c
+		names[sg.Name()] = name
+
+		parent := outer
+		if p, ok := other.clusterParent[sg]; ok {
+			parent = clusters[p]
+		}
+		g.addCluster(parent, c)
+	}
+
+	nodes := make(map[*dot.Node]*dot.Node, len(other.order))
+	for _, n := range other.order {
+		c := copyNode(n, prefix+": "+n.Name())
+		nodes[n] = c
+
+		parent := outer
+		if p, ok := other.parent[n]; ok {
+			parent = clusters[p]
+		}
+		g.addNodeTo(parent, c)
+	}
+
+	for k, n := range other.nodes {
+		if c, ok := nodes[n]; ok {
+			g.track(prefix+"/"+k, c)
+			if info, ok := other.info[k]; ok {
+				g.info[prefix+"/"+k] = info
+			}
+		}
+	}
+
+	for _, e := range other.edges {
+		src, ok := nodes[e.Source()]
+		if !ok {
+			continue
+		}
+		dst, ok := nodes[e.Destination()]
+		if !ok {
+			continue
+		}
+		c := copyEdge(e.Edge, src, dst)
+		for _, attr := range []string{"lhead", "ltail"} {
+			if name, ok := names[c.Get(attr)]; ok {
+				_ = c.Set(attr, name)
+			}
+		}
+		g.addEdge(c, e.rel)
+	}
+}
@@ -0,0 +1,54 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSideBySide(t *testing.T) {
+	left := New("default")
+	left.AddBroker(newBroker("default", "default"))
+	left.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+	right := New("default")
+	right.AddBroker(newBroker("default", "default"))
+
+	g := SideBySide(left, right, "before", "after")
+
+	outermost := func(key string) string {
+		n, ok := g.nodes[key]
+		if !ok {
+			t.Fatalf("no node %q", key)
+		}
+		sg := g.parent[n]
+		for sg != nil && g.clusterParent[sg] != nil {
+			sg = g.clusterParent[sg]
+		}
+		if sg == nil {
+			return ""
+		}
+		return sg.Name()
+	}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "left/eventing.knative.dev/broker/default", want: "cluster_left"},
+		{key: "left/eventing.knative.dev/trigger/t", want: "cluster_left"},
+		{key: "right/eventing.knative.dev/broker/default", want: "cluster_right"},
+	}
+	for _, tt := range tests {
+		if got := outermost(tt.key); got != tt.want {
+			t.Errorf("%s is in %q, want %q", tt.key, got, tt.want)
+		}
+	}
+
+	out := g.String()
+	for _, want := range []string{"subgraph cluster_left {", "subgraph cluster_right {", `label=before`, `label=after`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output has no %q:\n%s", want, out)
+		}
+	}
+	if got := len(g.edges); got != len(left.edges)+len(right.edges) {
+		t.Errorf("got %d edges, want the %d of both sides", got, len(left.edges)+len(right.edges))
+	}
+}
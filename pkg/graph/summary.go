@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tmc/dot"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SummarizeByNamespace graphs objs with one node per namespace and an edge
+// from one namespace to another when events flow between resources in them,
+// labeled with the number of such flows.
+func SummarizeByNamespace(objs []runtime.Object) *Graph {
+	full := New("", WithNamespaceClusters(true))
+	for _, obj := range objs {
+		full.Add(obj)
+	}
+
+	namespaces := make(map[*dot.Node]string, len(full.nodes))
+	for k, n := range full.nodes {
+		if ns := full.info[k].namespace; ns != "" {
+			namespaces[n] = ns
+		}
+	}
+
+	g := New("")
+	_ = g.Set("label", "Event flow between namespaces")
+
+	flows := make(map[[2]string]int)
+	for _, e := range full.edges {
+		from, to := namespaces[e.Source()], namespaces[e.Destination()]
+		if from == "" || to == "" || from == to {
+			continue
+		}
+		flows[[2]string{from, to}]++
+	}
+
+	var names []string
+	for _, ns := range namespaces {
+		if _, ok := g.nodes[namespaceKey(ns)]; ok {
+			continue
+		}
+		n := dot.NewNode("Namespace " + ns)
+		_ = n.Set("shape", "folder")
+		_ = n.Set("label", ns)
+		g.track(namespaceKey(ns), n)
+		g.info[namespaceKey(ns)] = nodeInfo{kind: "Namespace", name: ns}
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+	for _, ns := range names {
+		g.addNodeTo(nil, g.nodes[namespaceKey(ns)])
+	}
+
+	pairs := make([][2]string, 0, len(flows))
+	for pair := range flows {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	for _, pair := range pairs {
+		e := dot.NewEdge(g.nodes[namespaceKey(pair[0])], g.nodes[namespaceKey(pair[1])])
+		_ = e.Set("label", fmt.Sprintf("%d", flows[pair]))
+		g.addEdge(e, "")
+	}
+	return g
+}
+
+func namespaceKey(name string) string {
+	return key("", "Namespace", name)
+}
@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func TestSummarizeByNamespace(t *testing.T) {
+	toB := duckv1.Destination{Ref: &duckv1.KReference{
+		Kind:       "Service",
+		APIVersion: "serving.knative.dev/v1",
+		Namespace:  "b",
+		Name:       "svc",
+	}}
+	brokerA, brokerB := newBroker("a", "default"), newBroker("b", "default")
+	svcA, svcB := newKnService("a", "svc"), newKnService("b", "svc")
+	local := newTrigger("a", "local", "default", *serviceRef("svc"))
+	remote := newTrigger("a", "remote", "default", toB)
+	inB := newTrigger("b", "local", "default", *serviceRef("svc"))
+	toBrokerB := newKnService("a", "svc", corev1.EnvVar{
+		Name:  "SINK",
+		Value: "http://broker-ingress.knative-eventing.svc.cluster.local/b/default",
+	})
+
+	tests := []struct {
+		name string
+		objs []runtime.Object
+		want []Edge
+	}{{
+		name: "cross namespace flow",
+		objs: []runtime.Object{&brokerA, &svcB, &remote},
+		want: []Edge{{From: namespaceKey("a"), To: namespaceKey("b")}},
+	}, {
+		name: "trigger added before its subscriber",
+		objs: []runtime.Object{&brokerA, &brokerB, &local, &toBrokerB},
+		want: []Edge{{From: namespaceKey("a"), To: namespaceKey("b")}},
+	}, {
+		name: "same named resources stay apart",
+		objs: []runtime.Object{&brokerA, &brokerB, &svcA, &svcB, &local, &inB},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := SummarizeByNamespace(tt.objs)
+
+			got := g.Edges()
+			if len(got) != len(tt.want) {
+				t.Fatalf("got edges %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("edge %d = %v, want %v", i, got[i], tt.want[i])
+				}
+				if label := g.edges[i].Get("label"); label != "1" {
+					t.Errorf("edge %d is labeled %q, want one flow", i, label)
+				}
+			}
+			for _, ns := range []string{"a", "b"} {
+				if _, ok := g.nodes[namespaceKey(ns)]; !ok {
+					t.Errorf("no node for namespace %s", ns)
+				}
+			}
+		})
+	}
+}
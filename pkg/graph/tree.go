@@ -0,0 +1,54 @@
+package graph
+
+import (
+	"strings"
+)
+
+// Tree renders the event flow as a text tree drawn with box-drawing
+// characters, for terminals. There is one tree per source, or other node
+// nothing sends to, and per broker or channel, with the resources events
+// reach from it nested below. Brokers and channels reached from another root
+// are listed but not expanded again.
+func (g *Graph) Tree() string {
+	adj := g.adjacency()
+	incoming := make(map[string]bool)
+	for _, tos := range adj {
+		for _, to := range tos {
+			incoming[to] = true
+		}
+	}
+
+	b := &strings.Builder{}
+	for _, n := range g.order {
+		key := g.keyOf(n)
+		if _, ok := g.nodes[key]; !ok || len(adj[key]) == 0 {
+			continue
+		}
+		if incoming[key] && !g.isIngress(key) {
+			continue
+		}
+		b.WriteString(g.markdownName(key) + "\n")
+		g.treeChildren(b, key, adj, "", map[string]bool{key: true})
+	}
+	return b.String()
+}
+
+func (g *Graph) treeChildren(b *strings.Builder, key string, adj map[string][]string, prefix string, seen map[string]bool) {
+	var children []string
+	for _, next := range adj[key] {
+		if !seen[next] {
+			seen[next] = true
+			children = append(children, next)
+		}
+	}
+	for i, next := range children {
+		connector, indent := "├─ ", "│  "
+		if i == len(children)-1 {
+			connector, indent = "└─ ", "   "
+		}
+		b.WriteString(prefix + connector + g.markdownName(next) + "\n")
+		if !g.isIngress(next) {
+			g.treeChildren(b, next, adj, prefix+indent, seen)
+		}
+	}
+}
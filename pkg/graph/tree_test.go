@@ -0,0 +1,75 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	tests := []struct {
+		name string
+		add  func(g *Graph)
+		want string
+	}{{
+		name: "empty",
+		add:  func(*Graph) {},
+		want: "",
+	}, {
+		name: "trigger",
+		add: func(g *Graph) {
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+		},
+		want: `Broker default
+└─ Trigger t
+   └─ Service svc
+`,
+	}, {
+		name: "triggers",
+		add: func(g *Graph) {
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+			g.AddTrigger(newTrigger("default", "u", "default", *serviceRef("other")))
+		},
+		want: `Broker default
+├─ Trigger t
+│  └─ Service svc
+└─ Trigger u
+   └─ Service other
+`,
+	}, {
+		name: "source",
+		add: func(g *Graph) {
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+			g.AddSource(newSource("default", "ping", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"))
+		},
+		// The broker is listed under the source but only expanded as its own tree.
+		want: `Broker default
+└─ Trigger t
+   └─ Service svc
+PingSource ping
+└─ Broker default
+`,
+	}, {
+		name: "into a channel",
+		add: func(g *Graph) {
+			g.AddInMemoryChannel(newChannel("default", "ch"))
+			g.AddTrigger(newTrigger("default", "t", "default", *channelRef("ch")))
+			g.AddTrigger(newTrigger("default", "u", "default", *serviceRef("svc")))
+		},
+		want: `Broker default
+├─ Trigger t
+│  └─ InMemoryChannel ch
+└─ Trigger u
+   └─ Service svc
+`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			tt.add(g)
+
+			if got := g.Tree(); got != tt.want {
+				t.Errorf("Tree() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
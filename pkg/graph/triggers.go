@@ -23,6 +23,11 @@ func ForTriggers(client dynamic.Interface, ns string) (string, []knative.YamlVie
 		g.AddBroker(broker)
 	}
 
+	// load the event types
+	for _, et := range c.EventTypes(ns, &yv) {
+		g.AddEventType(et)
+	}
+
 	// load the triggers
 	for _, trigger := range c.Triggers(ns, &yv) {
 		g.AddTrigger(trigger)
@@ -63,6 +68,16 @@ func ForSubscriptions(client dynamic.Interface, ns string) string {
 		g.AddBroker(broker)
 	}
 
+	//for _, channel := range c.Channels(ns, &yv) {
+	//	g.AddChannel(channel)
+	//}
+
+	// load the channels before the triggers, so triggers delivering to a
+	// channel connect to its cluster.
+	for _, channel := range c.InMemoryChannels(ns, &yv) {
+		g.AddInMemoryChannel(channel)
+	}
+
 	// load the sources
 	for _, source := range c.Sources(ns, &yv) {
 		g.AddSource(source)
@@ -78,14 +93,6 @@ func ForSubscriptions(client dynamic.Interface, ns string) string {
 		g.AddKnService(service)
 	}
 
-	//for _, channel := range c.Channels(ns, &yv) {
-	//	g.AddChannel(channel)
-	//}
-
-	for _, channel := range c.InMemoryChannels(ns, &yv) {
-		g.AddInMemoryChannel(channel)
-	}
-
 	for _, subscription := range c.Subscriptions(ns, &yv) {
 		g.AddSubscription(subscription)
 	}
@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/tmc/dot"
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+// addTriggerRow renders the trigger as a "type → subscriber" row of the
+// trigger table inside the broker cluster, instead of as its own node.
+func (g *Graph) addTriggerRow(bk string, bn *dot.Node, trigger eventingv1beta1.Trigger) {
+	tk := triggerTableKey(bk)
+	tn, ok := g.nodes[tk]
+	if !ok {
+		tn = dot.NewNode("Triggers " + bk)
+		_ = tn.Set("shape", "plaintext")
+		if sg, ok := g.subgraphs[bk]; ok {
+			g.addNodeTo(sg, tn)
+		} else {
+			g.addNodeTo(nil, tn)
+		}
+		g.track(tk, tn)
+
+		e := dot.NewEdge(bn, tn)
+		g.addEdge(e, relTrigger)
+	}
+
+	eventType := "*"
+	if trigger.Spec.Filter != nil {
+		if t := trigger.Spec.Filter.Attributes["type"]; t != "" {
+			eventType = t
+		}
+	}
+
+	port := fmt.Sprintf("t%d", len(g.triggerRows[tk]))
+	g.triggerRows[tk] = append(g.triggerRows[tk], fmt.Sprintf(`<TR><TD>%s</TD><TD PORT="%s">%s</TD></TR>`,
+		html.EscapeString(eventType),
+		port,
+		html.EscapeString(destinationName(&trigger.Spec.Subscriber)),
+	))
+	_ = tn.Set("label", fmt.Sprintf(`<<TABLE BORDER="0" CELLBORDER="1" CELLSPACING="0"><TR><TD><B>type</B></TD><TD><B>subscriber</B></TD></TR>%s</TABLE>>`,
+		strings.Join(g.triggerRows[tk], "")))
+
+	if sub := g.getOrCreateSubscriber(trigger.Namespace, &trigger.Spec.Subscriber); sub != nil {
+		e := dot.NewEdge(tn, sub)
+		_ = e.Set("tailport", port)
+		g.setEdgeColorForStatus(e, trigger.Status.Status)
+		g.setEdgeDelivery(e, g.brokerDelivery[bk])
+		g.addEdge(e, relSubscriber)
+	}
+}
+
+func destinationName(dest *duckv1.Destination) string {
+	switch {
+	case dest == nil:
+		return "?"
+	case dest.Ref != nil:
+		return dest.Ref.Name
+	case dest.URI != nil:
+		return dest.URI.String()
+	}
+	return "?"
+}
+
+func triggerTableKey(bk string) string {
+	return bk + "/triggers"
+}
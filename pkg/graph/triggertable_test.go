@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+)
+
+func TestWithTriggerTable(t *testing.T) {
+	g := New("default", WithTriggerTable(true))
+	g.AddBroker(newBroker("default", "default"))
+	typed := newTrigger("default", "typed", "default", *serviceRef("svc-a"))
+	typed.Spec.Filter = &eventingv1beta1.TriggerFilter{
+		Attributes: eventingv1beta1.TriggerFilterAttributes{"type": "dev.example.created"},
+	}
+	g.AddTrigger(typed)
+	g.AddTrigger(newTrigger("default", "any", "default", *serviceRef("svc-b")))
+
+	table := g.nodes["eventing.knative.dev/broker/default/triggers"]
+	if table == nil {
+		t.Fatal("no trigger table node")
+	}
+	label := table.Get("label")
+	for _, row := range []string{
+		`<TR><TD>dev.example.created</TD><TD PORT="t0">svc-a</TD></TR>`,
+		`<TR><TD>*</TD><TD PORT="t1">svc-b</TD></TR>`,
+	} {
+		if !strings.Contains(label, row) {
+			t.Errorf("table label %q has no row %q", label, row)
+		}
+	}
+
+	tests := []struct {
+		subscriber string
+		port       string
+	}{
+		{subscriber: "serving.knative.dev/service/svc-a", port: "t0"},
+		{subscriber: "serving.knative.dev/service/svc-b", port: "t1"},
+	}
+	for _, tt := range tests {
+		e := findEdge(t, g, "eventing.knative.dev/broker/default/triggers", tt.subscriber)
+		if got := e.Get("tailport"); got != tt.port {
+			t.Errorf("edge to %s leaves port %q, want %q", tt.subscriber, got, tt.port)
+		}
+	}
+	if _, ok := g.nodes["eventing.knative.dev/trigger/typed"]; ok {
+		t.Error("trigger drawn as its own node")
+	}
+}
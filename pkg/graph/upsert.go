@@ -0,0 +1,106 @@
+package graph
+
+import (
+	"sync"
+
+	"github.com/tmc/dot"
+	"k8s.io/apimachinery/pkg/runtime"
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
+)
+
+// Upsert adds obj to the graph, or if a node for it already exists, updates
+// that node in place with the label and style obj would be drawn with now.
+// Edges drawn for the earlier version of obj are kept as they are.
+func (g *Graph) Upsert(obj runtime.Object) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := g.objectKey(obj)
+	existing, ok := g.nodes[key]
+	if !ok {
+		return g.add(obj)
+	}
+
+	// obj is drawn alone to take the label and style of its node from, so
+	// updates cost the same however large the graph is.
+	s := g.scratch()
+	if !s.add(obj) {
+		return false
+	}
+	fresh, ok := s.nodes[key]
+	if !ok {
+		return true
+	}
+	for _, attr := range nodeAttributes {
+		if attr == "group" {
+			// Set from where the node is placed, which the update keeps.
+			continue
+		}
+		v := fresh.Get(attr)
+		if attr == "label" && v == "" {
+			// Unlabeled nodes show their name.
+			v = fresh.Name()
+		}
+		if v != existing.Get(attr) {
+			_ = existing.Set(attr, v)
+		}
+	}
+	g.info[key] = s.info[key]
+	if s.notReady[fresh] {
+		g.notReady[existing] = true
+	} else {
+		delete(g.notReady, existing)
+	}
+	return true
+}
+
+// scratch returns an empty graph with the options of g, to draw objects on
+// without changing g.
+func (g *Graph) scratch() *Graph {
+	s := *g
+	s.mu = new(sync.Mutex)
+	s.Graph = dot.NewGraph(g.Name())
+	s.nodes = make(map[string]*dot.Node)
+	s.keys = make(map[*dot.Node]string)
+	s.subgraphs = make(map[string]*dot.SubGraph)
+	s.dnsToKey = make(map[string]string)
+	s.info = make(map[string]nodeInfo)
+	s.edges = nil
+	s.parts = make(map[string][]string)
+
+	s.order = nil
+	s.parent = make(map[*dot.Node]*dot.SubGraph)
+	s.clusters = nil
+	s.clusterParent = make(map[*dot.SubGraph]*dot.SubGraph)
+	s.clusterNames = make(map[string]int)
+	s.edgeIDs = make(map[string]int)
+	s.flattened = make(map[*dot.SubGraph]*dot.SubGraph)
+	s.edgeCount = 0
+
+	s.triggerRows = make(map[string][]string)
+	s.brokerDelivery = make(map[string]*eventingduckv1beta1.DeliverySpec)
+	s.pendingDeadLetters = make(map[string][]*dot.Node)
+	s.sequenceSteps = make(map[string][]string)
+	s.sequenceRanked = make(map[*dot.SubGraph]bool)
+	s.triggersRanked = make(map[*dot.SubGraph]int)
+	s.brokerTypes = make(map[string][]string)
+	s.brokerFilters = make(map[string][]string)
+	s.triggerFilters = make(map[string][]triggerFilter)
+	s.notReady = make(map[*dot.Node]bool)
+	s.opacity = make(map[*dot.Node]float64)
+	s.fills = make(map[*dot.Node]fill)
+	s.ageFills = make(map[*dot.Node]string)
+
+	s.warnings = nil
+	s.truncated = false
+	s.sourceGroups = make(map[string]*dot.SubGraph)
+	s.degreeSuffix = make(map[*dot.Node]string)
+	s.titleSuffix = ""
+	s.legend = nil
+	s.timestamp = nil
+	s.partOfGroups = make(map[string]*dot.SubGraph)
+	s.namespaceGroups = make(map[string]*dot.SubGraph)
+	s.triggerGroups = make(map[string]*dot.SubGraph)
+	s.ports = make(map[string]int)
+	return &s
+}
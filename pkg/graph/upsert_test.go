@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func TestUpsert(t *testing.T) {
+	filtered := newTrigger("default", "t", "default", *serviceRef("svc"))
+	filtered.Spec.Filter = &eventingv1beta1.TriggerFilter{
+		Attributes: eventingv1beta1.TriggerFilterAttributes{"type": "dev.example.created"},
+	}
+	ready := newKnService("default", "svc")
+	ready.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionReady, Status: corev1.ConditionTrue}}
+	failed := newKnService("default", "svc")
+	failed.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionReady, Status: corev1.ConditionFalse}}
+
+	tests := []struct {
+		name      string
+		first     runtime.Object
+		then      runtime.Object
+		key       string
+		attr      string
+		want      string
+		wantNodes int
+	}{{
+		name:      "trigger gains a filter",
+		first:     triggerPtr(newTrigger("default", "t", "default", *serviceRef("svc"))),
+		then:      &filtered,
+		key:       "eventing.knative.dev/trigger/t",
+		attr:      "label",
+		want:      "Trigger t\ntype: dev.example.created",
+		wantNodes: 3,
+	}, {
+		name:      "trigger loses its filter",
+		first:     &filtered,
+		then:      triggerPtr(newTrigger("default", "t", "default", *serviceRef("svc"))),
+		key:       "eventing.knative.dev/trigger/t",
+		attr:      "label",
+		want:      "Trigger t",
+		wantNodes: 3,
+	}, {
+		name:      "service fails",
+		first:     &ready,
+		then:      &failed,
+		key:       "serving.knative.dev/service/svc",
+		attr:      "color",
+		want:      getColorMapForStatus(failed.Status.Status, defaultReadinessPalette)["color"],
+		wantNodes: 2,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			if !g.Upsert(tt.first) || !g.Upsert(tt.then) {
+				t.Fatal("Upsert() = false, want true")
+			}
+
+			if got := len(g.order); got != tt.wantNodes {
+				t.Errorf("graph has %d nodes, want %d", got, tt.wantNodes)
+			}
+			if got := g.nodes[tt.key].Get(tt.attr); got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.attr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpsertConcurrent(t *testing.T) {
+	g := New("default")
+	g.AddBroker(newBroker("default", "default"))
+	trigger := newTrigger("default", "t", "default", *serviceRef("svc"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.Upsert(&trigger)
+		}()
+	}
+	wg.Wait()
+
+	if got := len(edgesOf(g, relTrigger)); got != 1 {
+		t.Errorf("drew %d broker to trigger edges, want 1", got)
+	}
+	if got := len(g.order); got != 3 {
+		t.Errorf("graph has %d nodes, want 3", got)
+	}
+}
+
+func TestUpsertKeepsPlacement(t *testing.T) {
+	g := New("default", WithMaxClusterDepth(1), WithGroupTriggersBySubscriber(true))
+	g.AddBroker(newBroker("default", "default"))
+	trigger := newTrigger("default", "t", "default", *serviceRef("svc"))
+	g.AddTrigger(trigger)
+	key := "eventing.knative.dev/trigger/t"
+	group := g.nodes[key].Get("group")
+
+	g.Upsert(&trigger)
+	if got := g.nodes[key].Get("group"); got == "" || got != group {
+		t.Errorf("group = %q after Upsert, want %q", got, group)
+	}
+	if got := len(g.order); got != 3 {
+		t.Errorf("graph has %d nodes, want 3", got)
+	}
+}
+
+func triggerPtr(t eventingv1beta1.Trigger) *eventingv1beta1.Trigger {
+	return &t
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tmc/dot"
+)
+
+// Validate checks the bookkeeping kept alongside the drawn graph: the
+// endpoints of every edge are tracked nodes, every node placed in a cluster
+// was added through the graph, every cluster holding a node is tracked, and
+// every domain name maps to a node key. It reports every problem found.
+func (g *Graph) Validate() error {
+	var problems []string
+
+	for _, e := range g.edges {
+		for _, n := range []*dot.Node{e.Source(), e.Destination()} {
+			if k, ok := g.keys[n]; !ok || g.nodes[k] != n {
+				problems = append(problems, fmt.Sprintf("edge %q has endpoint %q that is not tracked", e.Get("id"), n.Name()))
+			}
+		}
+	}
+	added := make(map[*dot.Node]bool, len(g.order))
+	for _, n := range g.order {
+		added[n] = true
+	}
+	tracked := make(map[*dot.SubGraph]bool, len(g.clusters))
+	for _, sg := range g.clusters {
+		tracked[sg] = true
+	}
+	for n, sg := range g.parent {
+		if !added[n] {
+			problems = append(problems, fmt.Sprintf("node %q in cluster %q was not added", n.Name(), sg.Name()))
+		}
+		if !tracked[sg] {
+			problems = append(problems, fmt.Sprintf("node %q is in untracked cluster %q", n.Name(), sg.Name()))
+		}
+	}
+	for sg, parent := range g.clusterParent {
+		if !tracked[sg] || !tracked[parent] {
+			problems = append(problems, fmt.Sprintf("cluster %q is nested in untracked cluster %q", sg.Name(), parent.Name()))
+		}
+	}
+
+	for dns, k := range g.dnsToKey {
+		if _, ok := g.nodes[k]; !ok {
+			problems = append(problems, fmt.Sprintf("domain %q maps to unknown key %q", dns, k))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("invalid graph: %s", strings.Join(problems, "; "))
+}
@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tmc/dot"
+)
+
+func TestValidate(t *testing.T) {
+	const (
+		broker  = "eventing.knative.dev/broker/default"
+		trigger = "eventing.knative.dev/trigger/t"
+	)
+	tests := []struct {
+		name    string
+		corrupt func(g *Graph)
+		want    string // part of the problem reported
+	}{{
+		name:    "valid",
+		corrupt: func(*Graph) {},
+	}, {
+		name: "untracked endpoint",
+		corrupt: func(g *Graph) {
+			delete(g.keys, g.nodes[trigger])
+		},
+		want: `edge "eventing.knative.dev/broker/default->eventing.knative.dev/trigger/t:trigger" has endpoint "Trigger t" that is not tracked`,
+	}, {
+		name: "endpoint replaced",
+		corrupt: func(g *Graph) {
+			g.nodes[broker] = dot.NewNode("stray")
+		},
+		want: `has endpoint "Broker http://broker-ingress.knative-eventing.svc.cluster.local/default/default" that is not tracked`,
+	}, {
+		name: "node never added",
+		corrupt: func(g *Graph) {
+			g.parent[dot.NewNode("stray")] = g.subgraphs[broker]
+		},
+		want: `node "stray" in cluster "cluster_0" was not added`,
+	}, {
+		name: "untracked cluster",
+		corrupt: func(g *Graph) {
+			g.parent[g.nodes[trigger]] = dot.NewSubgraph("cluster_stray")
+		},
+		want: `node "Trigger t" is in untracked cluster "cluster_stray"`,
+	}, {
+		name: "dangling domain",
+		corrupt: func(g *Graph) {
+			g.dnsToKey["http://gone.example.com"] = "eventing.knative.dev/broker/gone"
+		},
+		want: `domain "http://gone.example.com" maps to unknown key "eventing.knative.dev/broker/gone"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+			seq := newSequence("default", "seq", "first")
+			seq.Spec.Reply = serviceRef("svc")
+			g.AddSequence(seq)
+			p := newParallel("default", "par", "first")
+			p.Spec.Reply = serviceRef("svc")
+			g.AddParallel(p)
+			tt.corrupt(g)
+
+			err := g.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want it to report %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/tmc/dot"
+)
+
+// Warnings returns the problems found while building the graph, in the
+// order they were found.
+func (g *Graph) Warnings() []string {
+	return append([]string(nil), g.warnings...)
+}
+
+func (g *Graph) warn(format string, a ...interface{}) {
+	g.warnings = append(g.warnings, fmt.Sprintf(format, a...))
+}
+
+// malformed records a problem with an object being added as a warning and,
+// with WithErrorBanners, draws it as a red banner node. Each problem is only
+// recorded once.
+func (g *Graph) malformed(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	for _, w := range g.warnings {
+		if w == msg {
+			return
+		}
+	}
+	g.warnings = append(g.warnings, msg)
+	if !g.errorBanners {
+		return
+	}
+	n := dot.NewNode("ERROR: " + msg)
+	_ = n.Set("shape", "box")
+	_ = n.Set("style", "filled")
+	_ = n.Set("fillcolor", "red")
+	_ = n.Set("fontcolor", "white")
+	g.addNodeTo(nil, n)
+}
+
+// full reports whether the graph reached its node cap. It is checked before
+// each node is created, so an object whose nodes do not all fit is drawn in
+// part. The first time the cap is hit a warning is recorded.
+func (g *Graph) full() bool {
+	if g.maxNodes <= 0 || len(g.nodes) < g.maxNodes {
+		return false
+	}
+	if !g.truncated {
+		g.truncated = true
+		g.warn("graph truncated at %d nodes", g.maxNodes)
+	}
+	return true
+}
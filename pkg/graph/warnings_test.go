@@ -0,0 +1,161 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestWithMaxNodes(t *testing.T) {
+	tests := []struct {
+		name      string
+		max       int
+		add       func(g *Graph)
+		wantNodes int
+		truncated bool
+	}{{
+		name: "under the cap",
+		max:  3,
+		add: func(g *Graph) {
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+		},
+		wantNodes: 3,
+	}, {
+		name: "subscriber past the cap",
+		max:  2,
+		add: func(g *Graph) {
+			g.AddBroker(newBroker("default", "default"))
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+		},
+		wantNodes: 2,
+		truncated: true,
+	}, {
+		name: "trigger past the placeholder broker",
+		max:  1,
+		add: func(g *Graph) {
+			g.AddTrigger(newTrigger("default", "t", "default", *serviceRef("svc")))
+		},
+		wantNodes: 1,
+		truncated: true,
+	}, {
+		name: "event type past the placeholder broker",
+		max:  1,
+		add: func(g *Graph) {
+			g.AddEventType(newEventType("default", "et", "default", "dev.example.created"))
+		},
+		wantNodes: 1,
+		truncated: true,
+	}, {
+		name: "unknown sink past the cap",
+		max:  1,
+		add: func(g *Graph) {
+			g.AddSource(newSource("default", "ping", "http://elsewhere.example.com"))
+		},
+		wantNodes: 1,
+		truncated: true,
+	}, {
+		name: "adding stops at the cap",
+		max:  1,
+		add: func(g *Graph) {
+			g.AddBroker(newBroker("default", "a"))
+			g.AddBroker(newBroker("default", "b"))
+		},
+		wantNodes: 1,
+		truncated: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithMaxNodes(tt.max))
+			tt.add(g)
+
+			if got := len(g.nodes); got != tt.wantNodes {
+				t.Errorf("got %d nodes, want %d", got, tt.wantNodes)
+			}
+			var warned bool
+			for _, w := range g.Warnings() {
+				warned = warned || w == fmt.Sprintf("graph truncated at %d nodes", tt.max)
+			}
+			if warned != tt.truncated {
+				t.Errorf("truncation warned = %v, want %v, warnings %v", warned, tt.truncated, g.Warnings())
+			}
+			if err := g.Validate(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestKnServiceNoSinkEnvWarning(t *testing.T) {
+	tests := []struct {
+		name string
+		env  []corev1.EnvVar
+		want []string
+	}{{
+		name: "no env",
+		want: []string{"serving.knative.dev/service/svc has no sink env"},
+	}, {
+		name: "other env",
+		env:  []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}},
+		want: []string{"serving.knative.dev/service/svc has no sink env"},
+	}, {
+		name: "sink env",
+		env:  []corev1.EnvVar{{Name: "SINK", Value: "http://example.com"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default")
+			g.AddKnService(newKnService("default", "svc", tt.env...))
+
+			if got := g.Warnings(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Warnings() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithErrorBanners(t *testing.T) {
+	const banner = "ERROR: eventing.knative.dev/broker/broken has no address"
+	tests := []struct {
+		name    string
+		enabled bool
+		want    int
+	}{
+		{name: "disabled", enabled: false, want: 0},
+		{name: "enabled", enabled: true, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("default", WithErrorBanners(tt.enabled))
+			broken := newBroker("default", "broken")
+			broken.Status.Address.URL = nil
+			g.AddBroker(broken)
+			// The same problem is only drawn once.
+			g.AddBroker(broken)
+			g.AddBroker(newBroker("default", "default"))
+
+			banners := 0
+			for _, n := range g.order {
+				if !strings.HasPrefix(n.Name(), "ERROR: ") {
+					continue
+				}
+				banners++
+				if n.Name() != banner {
+					t.Errorf("banner %q, want %q", n.Name(), banner)
+				}
+				if got := n.Get("fillcolor"); got != "red" {
+					t.Errorf("banner is filled %q, want red", got)
+				}
+			}
+			if banners != tt.want {
+				t.Errorf("drew %d error banners, want %d", banners, tt.want)
+			}
+			if want := []string{"eventing.knative.dev/broker/broken has no address"}; !reflect.DeepEqual(g.Warnings(), want) {
+				t.Errorf("Warnings() = %q, want %q", g.Warnings(), want)
+			}
+		})
+	}
+}